@@ -0,0 +1,154 @@
+package main
+
+import (
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"pong/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// arenaDir holds the Pong arenas, one .txt layout each, listed in file name
+// order. The editor saves new ones here too.
+const arenaDir = "arenas"
+
+// arenaFile is an arena and the file it came from, so the editor can save
+// changes back over it.
+type arenaFile struct {
+	path  string
+	arena *sim.Arena
+}
+
+// obstacle colours, portals take theirs from the pair they belong to
+var (
+	wallColor    = color.RGBA{150, 200, 200, 255}
+	bumperColor  = color.RGBA{250, 140, 60, 255}
+	portalColors = []color.RGBA{
+		{100, 200, 250, 255},
+		{200, 120, 230, 255},
+		{100, 220, 120, 255},
+		{250, 200, 60, 255},
+	}
+)
+
+// loadArenas reads every arena in dir. Like the Breakout levels, broken
+// files are reported and skipped.
+func loadArenas(dir string) []arenaFile {
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+
+	sort.Strings(paths)
+
+	arenas := []arenaFile{}
+
+	for _, path := range paths {
+
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		arena, err := sim.ParseArena(filepath.Base(path), data)
+
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		arenas = append(arenas, arenaFile{path: path, arena: arena})
+	}
+
+	return arenas
+}
+
+// currentArena is the arena picked in the menu, nil for the open court.
+func (g *Game) currentArena() *sim.Arena {
+
+	if g.arena < 0 || g.arena >= len(g.arenas) {
+		return nil
+	}
+
+	return g.arenas[g.arena].arena
+}
+
+func (g *Game) arenaName() string {
+
+	if a := g.currentArena(); a != nil {
+		return a.Name
+	}
+
+	return "Open Court"
+}
+
+// nextArena steps through the arenas in the menu, the open court first.
+func (g *Game) nextArena() {
+
+	g.arena++
+
+	if g.arena >= len(g.arenas) {
+		g.arena = -1
+	}
+}
+
+// obstacleColor picks the colour of obstacle i of obstacles. The nth pair
+// of portals, counted in the order they're listed, gets the nth colour.
+func obstacleColor(obstacles []sim.Obstacle, i int) color.RGBA {
+
+	switch obstacles[i].Kind {
+
+	case sim.Bumper:
+		return bumperColor
+
+	case sim.Portal:
+		portals := 0
+		for _, o := range obstacles[:i] {
+			if o.Kind == sim.Portal {
+				portals++
+			}
+		}
+
+		return portalColors[portals/2%len(portalColors)]
+	}
+
+	return wallColor
+}
+
+// drawObstacles draws the arena between the last two steps, the same way
+// drawObject does paddles and balls.
+func (g *Game) drawObstacles(screen *ebiten.Image, interpolate bool) {
+
+	w := g.world
+	alpha := g.clock.Alpha()
+
+	for i, o := range w.Obstacles {
+
+		x, y := o.X, o.Y
+
+		if interpolate && i < len(g.prev.Obstacles) {
+			prev := g.prev.Obstacles[i]
+			x = prev.X + (o.X-prev.X)*alpha
+			y = prev.Y + (o.Y-prev.Y)*alpha
+		}
+
+		clr := obstacleColor(w.Obstacles, i)
+
+		if o.Kind == sim.Portal {
+			vector.StrokeRect(screen, float32(x)+1, float32(y)+1, float32(o.W)-2, float32(o.H)-2, 2, clr, false)
+			continue
+		}
+
+		vector.DrawFilledRect(screen, float32(x), float32(y), float32(o.W), float32(o.H), clr, false)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"pong/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font/basicfont"
+)
+
+// levelDir holds the Breakout stages, one .txt layout per level, played in
+// file name order. New stages only need a new file, not a rebuild.
+const levelDir = "levels"
+
+// brick colours by hit points left
+var brickColors = []color.RGBA{
+	{100, 200, 250, 255},
+	{100, 220, 120, 255},
+	{250, 200, 60, 255},
+	{250, 140, 60, 255},
+	{230, 90, 90, 255},
+}
+
+// loadLevels reads every level in dir. Broken files are reported and
+// skipped so one bad stage doesn't take the rest down with it.
+func loadLevels(dir string) []sim.Level {
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+
+	if err != nil {
+		log.Print(err)
+		return nil
+	}
+
+	sort.Strings(paths)
+
+	levels := []sim.Level{}
+
+	for _, path := range paths {
+
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		level, err := sim.ParseLevel(filepath.Base(path), data)
+
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		levels = append(levels, level)
+	}
+
+	return levels
+}
+
+func (g *Game) newBreakout() {
+
+	// PICK UP ANY STAGES ADDED SINCE THE LAST GAME
+	levels := loadLevels(levelDir)
+
+	if len(levels) == 0 {
+		log.Printf("no Breakout levels found in %v", levelDir)
+		return
+	}
+
+	g.breakout = sim.NewBreakout(levels, g.step, uint64(time.Now().UnixNano()))
+	g.prevBreakout = g.breakout.Clone()
+	g.recorded = false
+
+	g.clock = sim.Clock{Dt: g.step}
+	g.last = time.Now()
+
+	g.state = stateBreakout
+}
+
+func (g *Game) updateBreakout() {
+
+	b := g.breakout
+
+	if b.Phase == sim.GameOver || b.Phase == sim.GameWon {
+
+		if !g.recorded {
+			g.recordBreakout()
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			g.state = stateMenu
+			return
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+			b.Restart()
+			g.prevBreakout = b.Clone()
+			g.recorded = false
+		}
+
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.pause()
+		return
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(g.last).Seconds()
+	g.last = now
+
+	in := sim.Input{
+		Left:  ebiten.IsKeyPressed(ebiten.KeyA) || ebiten.IsKeyPressed(ebiten.KeyArrowLeft),
+		Right: ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyArrowRight),
+	}
+
+	for steps := g.clock.Advance(elapsed); steps > 0; steps-- {
+		g.prevBreakout = b.Clone()
+		b.Step(in)
+	}
+}
+
+// recordBreakout saves a finished game. Left holds the score and Right the
+// number of levels cleared.
+func (g *Game) recordBreakout() {
+
+	b := g.breakout
+
+	cleared := b.LevelIndex
+	result := "Game Over"
+
+	if b.Phase == sim.GameWon {
+		cleared = len(b.Levels)
+		result = "Cleared"
+	}
+
+	g.stats.Record(MatchRecord{
+		Date:     time.Now(),
+		Mode:     "Breakout",
+		Left:     b.Score,
+		Right:    cleared,
+		Winner:   result,
+		Duration: float64(b.Tick) * b.Dt,
+	}, b.Phase == sim.GameWon, b.Score)
+
+	g.recorded = true
+}
+
+func (g *Game) drawBreakout(screen *ebiten.Image) {
+
+	b := g.breakout
+
+	// BRICKS
+	for _, brick := range b.Bricks {
+
+		clr := brickColors[(brick.HP-1)%len(brickColors)]
+		vector.DrawFilledRect(screen, float32(brick.X), float32(brick.Y), float32(brick.W), float32(brick.H), clr, false)
+	}
+
+	// PADDLE AND BALL
+	samePhase := g.prevBreakout.Phase == b.Phase
+
+	g.drawObject(screen, g.prevBreakout.Paddle.Object, b.Paddle.Object, samePhase)
+	g.drawObject(screen, g.prevBreakout.Ball.Object, b.Ball.Object, samePhase)
+
+	// HUD
+	levelStr := fmt.Sprintf("Level %v: %v", b.LevelIndex+1, b.Levels[b.LevelIndex].Name)
+	text.Draw(screen, levelStr, basicfont.Face7x13, 10, 20, color.RGBA{150, 200, 200, 1})
+
+	scoreStr := fmt.Sprintf("Score: %v", b.Score)
+	text.Draw(screen, scoreStr, basicfont.Face7x13, screenWidth/2-35, 20, color.White)
+
+	livesStr := fmt.Sprintf("Lives: %v", b.Lives)
+	text.Draw(screen, livesStr, basicfont.Face7x13, screenWidth-80, 20, color.RGBA{100, 200, 250, 1})
+
+	switch b.Phase {
+
+	case sim.BreakoutServing:
+		text.Draw(screen, "Get Ready!", basicfont.Face7x13, screenWidth/2-35, screenHeight/2+60, color.White)
+
+	case sim.LevelCleared:
+		text.Draw(screen, "Level Cleared!", basicfont.Face7x13, screenWidth/2-49, screenHeight/2+60, color.White)
+
+	case sim.GameOver, sim.GameWon:
+		endStr := "Game Over!"
+		if b.Phase == sim.GameWon {
+			endStr = "You Cleared Every Level!"
+		}
+
+		text.Draw(screen, endStr, basicfont.Face7x13, (screenWidth-len(endStr)*7)/2, screenHeight/2+40, color.White)
+		text.Draw(screen, "Enter - Play Again    Esc - Menu", basicfont.Face7x13, screenWidth/2-112, screenHeight/2+80, color.RGBA{150, 200, 200, 1})
+	}
+}
@@ -0,0 +1,112 @@
+// Command replay checks that replay files still play out the way they did
+// when they were recorded, which makes any saved match a regression test
+// for the simulation:
+//
+//	go run ./cmd/replay testdata/*.pongreplay
+//
+// With -record it writes a new replay of a CPU against CPU match instead,
+// the way the ones in testdata were made:
+//
+//	go run ./cmd/replay -record testdata/arena.pongreplay -seed 3 -arena arenas/03-portals.txt
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"pong/replay"
+	"pong/sim"
+)
+
+func main() {
+
+	record := flag.String("record", "", "write a CPU vs CPU replay to this file instead of checking")
+	seed := flag.Uint64("seed", 1, "seed of the recorded match")
+	target := flag.Int("target", sim.DefaultTarget, "points needed to win the recorded match")
+	powerUps := flag.Bool("powerups", true, "record with power-ups")
+	arenaPath := flag.String("arena", "", "arena file to record in, an open court if empty")
+	flag.Parse()
+
+	if *record != "" {
+
+		cfg := sim.Config{Dt: sim.DefaultStep, Target: *target, Seed: *seed, PowerUps: *powerUps}
+
+		if *arenaPath != "" {
+
+			data, err := os.ReadFile(*arenaPath)
+
+			if err == nil {
+				cfg.Arena, err = sim.ParseArena(filepath.Base(*arenaPath), data)
+			}
+
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+
+		r := cpuMatch(cfg)
+
+		if err := r.Save(*record); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("%v: %v steps, %v - %v\n", *record, len(r.Inputs), r.Score[sim.Left], r.Score[sim.Right])
+		return
+	}
+
+	failed := false
+
+	for _, path := range flag.Args() {
+
+		r, err := replay.Load(path)
+
+		if err == nil {
+			err = r.Verify()
+		}
+
+		var mismatch *replay.MismatchError
+
+		switch {
+		case errors.As(err, &mismatch):
+			fmt.Printf("%v: FAIL, plays out differently than recorded (%v)\n", path, err)
+			failed = true
+
+		case err != nil:
+			fmt.Printf("%v: FAIL, %v\n", path, err)
+			failed = true
+
+		default:
+			fmt.Printf("%v: ok, %v steps, %v - %v\n", path, len(r.Inputs), r.Score[sim.Left], r.Score[sim.Right])
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// cpuMatch plays a whole match between two Normal CPUs.
+func cpuMatch(cfg sim.Config) *replay.Replay {
+
+	w := sim.NewWorld(cfg)
+	cpus := [2]*sim.CPU{sim.NewCPU(sim.Normal, cfg.Seed+1), sim.NewCPU(sim.Normal, cfg.Seed+2)}
+	inputs := [][2]sim.Input{}
+
+	for w.Phase != sim.MatchOver {
+
+		step := [2]sim.Input{}
+		for side, cpu := range cpus {
+			step[side] = cpu.Control(w, sim.Side(side))
+		}
+
+		inputs = append(inputs, step)
+		w.Step(step)
+	}
+
+	return replay.New(cfg, inputs)
+}
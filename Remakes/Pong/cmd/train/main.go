@@ -0,0 +1,136 @@
+// Command train fits a linear paddle policy against the CPU, entirely
+// headless, and writes its weights for the game's -policy flag:
+//
+//	go run ./cmd/train -out policy.json
+//	go run . -policy policy.json
+//
+// The search is the cross-entropy method: each generation samples weights
+// around a mean, plays them, and moves the mean to the best few.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+
+	"pong/env"
+	"pong/sim"
+)
+
+const params = int(env.NumActions) * (env.ObservationSize + 1)
+
+func main() {
+
+	out := flag.String("out", "policy.json", "where to write the trained weights")
+	generations := flag.Int("generations", 30, "generations to search for")
+	population := flag.Int("population", 24, "policies played each generation")
+	elite := flag.Int("elite", 5, "best policies the next generation is sampled around")
+	episodes := flag.Int("episodes", 3, "episodes played to score each policy")
+	level := flag.Int("level", int(sim.Normal), "CPU level to train against: 0 Easy, 1 Normal, 2 Hard")
+	target := flag.Int("target", 3, "points per episode")
+	seed := flag.Int64("seed", 1, "seed for the search and the matches")
+	flag.Parse()
+
+	rng := rand.New(rand.NewSource(*seed))
+
+	score := func(p *env.LinearPolicy) env.Result {
+
+		// A FRESH ENV AND OPPONENT EVERY TIME, SO EVERY CANDIDATE PLAYS THE SAME MATCHES
+		e := env.New(env.Options{
+			Opponent: sim.NewCPU(sim.Difficulty(*level), uint64(*seed)),
+			Target:   *target,
+			Seed:     uint64(*seed),
+		})
+
+		return env.Evaluate(e, p, *episodes)
+	}
+
+	mean := make([]float64, params)
+	spread := make([]float64, params)
+	for i := range spread {
+		spread[i] = 1
+	}
+
+	type candidate struct {
+		params []float64
+		result env.Result
+	}
+
+	for gen := 1; gen <= *generations; gen++ {
+
+		candidates := make([]candidate, *population)
+
+		for c := range candidates {
+
+			x := make([]float64, params)
+			for i := range x {
+				x[i] = mean[i] + rng.NormFloat64()*spread[i]
+			}
+
+			candidates[c] = candidate{x, score(toPolicy(x))}
+		}
+
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].result.Reward > candidates[j].result.Reward
+		})
+
+		best := candidates[:min(*elite, len(candidates))]
+
+		// REFIT THE SAMPLING DISTRIBUTION TO THE ELITE
+		for i := range mean {
+
+			sum := 0.0
+			for _, c := range best {
+				sum += c.params[i]
+			}
+			mean[i] = sum / float64(len(best))
+
+			variance := 0.0
+			for _, c := range best {
+				variance += (c.params[i] - mean[i]) * (c.params[i] - mean[i])
+			}
+
+			// A LITTLE EXTRA NOISE KEEPS THE SEARCH FROM STALLING
+			spread[i] = math.Sqrt(variance/float64(len(best))) + 0.05
+		}
+
+		r := best[0].result
+		fmt.Printf("generation %3v  best reward %6.2f  wins %v/%v  points %v-%v\n",
+			gen, r.Reward, r.Wins, r.Episodes, r.PointsFor, r.PointsAway)
+	}
+
+	policy := toPolicy(mean)
+	r := score(policy)
+
+	fmt.Printf("final policy: reward %.2f  wins %v/%v  points %v-%v\n",
+		r.Reward, r.Wins, r.Episodes, r.PointsFor, r.PointsAway)
+
+	if err := policy.Save(*out); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("wrote %v\n", *out)
+}
+
+// toPolicy unpacks a flat parameter vector: each action's weights followed
+// by its bias.
+func toPolicy(x []float64) *env.LinearPolicy {
+
+	p := &env.LinearPolicy{}
+	i := 0
+
+	for a := range p.Weights {
+		for j := range p.Weights[a] {
+			p.Weights[a][j] = x[i]
+			i++
+		}
+
+		p.Bias[a] = x[i]
+		i++
+	}
+
+	return p
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+
+	"pong/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font/basicfont"
+)
+
+// EDITOR LAYOUT
+const (
+	editorGrid   = 5  // obstacles snap to this many pixels
+	editorHandle = 8  // size of the resize handle in an obstacle's corner
+	newObstacle  = 30 // size of an obstacle placed with a click rather than a drag
+)
+
+// movePresets are what M steps through for the selected obstacle, starting
+// with standing still.
+var movePresets = []struct {
+	dx, dy, period float64
+}{
+	{0, 0, 0},
+	{0, 120, 4},
+	{0, -120, 4},
+	{120, 0, 4},
+	{-120, 0, 4},
+}
+
+// DRAG MODES
+type dragMode int
+
+const (
+	dragNone dragMode = iota
+	dragCreate
+	dragMove
+	dragResize
+)
+
+// editor lays out an arena with the mouse. It works on a copy, so nothing
+// changes on disk until it's saved.
+type editor struct {
+	arena    sim.Arena
+	path     string // file it's saved to, empty until the first save
+	kind     sim.ObstacleKind
+	selected int // index of the selected obstacle, -1 for none
+	drag     dragMode
+	grabX    float64 // where the obstacle was grabbed, or where a new one started
+	grabY    float64
+	preset   int
+	dirty    bool
+	message  string
+}
+
+// openEditor starts editing the arena picked in the menu, or a new one
+// for the open court.
+func (g *Game) openEditor() {
+
+	e := editor{kind: sim.Wall, selected: -1}
+
+	if a := g.currentArena(); a != nil {
+		e.arena = sim.Arena{Name: a.Name, Obstacles: append([]sim.Obstacle(nil), a.Obstacles...)}
+		e.path = g.arenas[g.arena].path
+	} else {
+		e.arena = sim.Arena{Name: "Custom"}
+	}
+
+	g.editor = e
+	g.state = stateEditor
+}
+
+func (g *Game) updateEditor() {
+
+	e := &g.editor
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.state = stateMenu
+		return
+	}
+
+	// KIND OF NEW OBSTACLES, AND OF THE SELECTED ONE
+	keys := map[ebiten.Key]sim.ObstacleKind{
+		ebiten.Key1: sim.Wall,
+		ebiten.Key2: sim.Bumper,
+		ebiten.Key3: sim.Portal,
+	}
+
+	for key, kind := range keys {
+		if inpututil.IsKeyJustPressed(key) {
+			e.kind = kind
+
+			if e.selected >= 0 {
+				e.arena.Obstacles[e.selected].Kind = kind
+				e.dirty = true
+			}
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) && e.selected >= 0 {
+		e.preset = (e.preset + 1) % len(movePresets)
+
+		o := &e.arena.Obstacles[e.selected]
+		o.MoveX, o.MoveY, o.Period = movePresets[e.preset].dx, movePresets[e.preset].dy, movePresets[e.preset].period
+		e.dirty = true
+	}
+
+	if (inpututil.IsKeyJustPressed(ebiten.KeyDelete) || inpututil.IsKeyJustPressed(ebiten.KeyBackspace)) && e.selected >= 0 {
+		e.remove(e.selected)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		g.saveArena()
+	}
+
+	e.updateMouse()
+}
+
+func (e *editor) updateMouse() {
+
+	cx, cy := ebiten.CursorPosition()
+	mx, my := float64(cx), float64(cy)
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) {
+		if i := e.under(mx, my); i >= 0 {
+			e.remove(i)
+		}
+		return
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		e.press(mx, my)
+	}
+
+	if e.drag == dragNone {
+		return
+	}
+
+	o := &e.arena.Obstacles[e.selected]
+
+	switch e.drag {
+
+	case dragCreate:
+		// FROM WHERE THE CLICK STARTED TO THE MOUSE, WHICHEVER WAY IT WENT
+		x0, y0 := snap(e.grabX), snap(e.grabY)
+		x1, y1 := snap(mx), snap(my)
+		o.Home.X, o.Home.Y = math.Min(x0, x1), math.Min(y0, y1)
+		o.Home.W, o.Home.H = math.Max(math.Abs(x1-x0), sim.MinObstacle), math.Max(math.Abs(y1-y0), sim.MinObstacle)
+
+	case dragMove:
+		o.Home.X = snap(math.Max(0, math.Min(mx-e.grabX, screenWidth-o.Home.W)))
+		o.Home.Y = snap(math.Max(0, math.Min(my-e.grabY, screenHeight-o.Home.H)))
+
+	case dragResize:
+		o.Home.W = math.Max(snap(mx)-o.Home.X, sim.MinObstacle)
+		o.Home.H = math.Max(snap(my)-o.Home.Y, sim.MinObstacle)
+	}
+
+	o.Object = o.Home
+	e.dirty = true
+
+	if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+
+		// A CLICK WITHOUT A DRAG PLACES ONE OF THE DEFAULT SIZE
+		if e.drag == dragCreate && o.Home.W == sim.MinObstacle && o.Home.H == sim.MinObstacle {
+			o.Home.X, o.Home.Y = snap(e.grabX-newObstacle/2), snap(e.grabY-newObstacle/2)
+			o.Home.W, o.Home.H = newObstacle, newObstacle
+			o.Object = o.Home
+		}
+
+		e.drag = dragNone
+	}
+}
+
+// press starts a drag: resizing from an obstacle's corner, moving it from
+// anywhere else on it, or drawing a new one on empty court.
+func (e *editor) press(mx, my float64) {
+
+	e.selected = e.under(mx, my)
+	e.message = ""
+
+	if e.selected < 0 {
+		e.arena.Obstacles = append(e.arena.Obstacles, sim.Obstacle{Kind: e.kind, Pair: -1})
+		e.selected = len(e.arena.Obstacles) - 1
+		e.preset = 0
+		e.drag = dragCreate
+		e.grabX, e.grabY = mx, my
+		return
+	}
+
+	o := e.arena.Obstacles[e.selected]
+	e.preset = 0
+
+	for i, p := range movePresets {
+		if p.dx == o.MoveX && p.dy == o.MoveY && p.period == o.Period {
+			e.preset = i
+		}
+	}
+
+	if mx >= o.Home.X+o.Home.W-editorHandle && my >= o.Home.Y+o.Home.H-editorHandle {
+		e.drag = dragResize
+		return
+	}
+
+	e.drag = dragMove
+	e.grabX, e.grabY = mx-o.Home.X, my-o.Home.Y
+}
+
+// under returns the topmost obstacle at the point, -1 for none.
+func (e *editor) under(x, y float64) int {
+
+	for i := len(e.arena.Obstacles) - 1; i >= 0; i-- {
+
+		o := e.arena.Obstacles[i].Home
+
+		if x >= o.X && x < o.X+o.W && y >= o.Y && y < o.Y+o.H {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (e *editor) remove(i int) {
+
+	e.arena.Obstacles = append(e.arena.Obstacles[:i], e.arena.Obstacles[i+1:]...)
+	e.selected = -1
+	e.drag = dragNone
+	e.dirty = true
+}
+
+func snap(v float64) float64 {
+
+	return math.Round(v/editorGrid) * editorGrid
+}
+
+// saveArena checks the layout the same way loading it will, then writes it
+// to its file, or to a new one in arenaDir. The menu picks it straight away.
+func (g *Game) saveArena() {
+
+	e := &g.editor
+
+	data, _ := e.arena.MarshalText()
+
+	if _, err := sim.ParseArena(e.arena.Name, data); err != nil {
+		e.message = err.Error()
+		return
+	}
+
+	if e.path == "" {
+
+		if err := os.MkdirAll(arenaDir, 0o755); err != nil {
+			e.message = err.Error()
+			return
+		}
+
+		// THE FIRST FREE custom-N.txt
+		for n := 1; ; n++ {
+
+			path := filepath.Join(arenaDir, fmt.Sprintf("custom-%v.txt", n))
+
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				e.path = path
+				e.arena.Name = fmt.Sprintf("Custom %v", n)
+				data, _ = e.arena.MarshalText()
+				break
+			}
+		}
+	}
+
+	if err := os.WriteFile(e.path, data, 0o644); err != nil {
+		e.message = err.Error()
+		return
+	}
+
+	e.dirty = false
+	e.message = "Saved to " + e.path
+
+	// RELOAD SO THE MENU SEES IT, AND PICK IT
+	g.arenas = loadArenas(arenaDir)
+	g.arena = -1
+
+	for i, a := range g.arenas {
+		if filepath.Clean(a.path) == filepath.Clean(e.path) {
+			g.arena = i
+		}
+	}
+}
+
+func (g *Game) drawEditor(screen *ebiten.Image) {
+
+	e := &g.editor
+	obstacles := e.arena.Obstacles
+
+	// WHERE PADDLES PLAY AND THE SERVE STARTS, BOTH KEPT CLEAR
+	marginColor := color.RGBA{40, 40, 60, 255}
+	vector.DrawFilledRect(screen, 0, 0, sim.ArenaMargin, screenHeight, marginColor, false)
+	vector.DrawFilledRect(screen, screenWidth-sim.ArenaMargin, 0, sim.ArenaMargin, screenHeight, marginColor, false)
+	vector.StrokeRect(screen, (screenWidth-sim.BallSize)/2, (screenHeight-sim.BallSize)/2, sim.BallSize, sim.BallSize, 1, color.White, false)
+
+	for i, o := range obstacles {
+
+		clr := obstacleColor(obstacles, i)
+		x, y, w, h := float32(o.Home.X), float32(o.Home.Y), float32(o.Home.W), float32(o.Home.H)
+
+		if o.Kind == sim.Portal {
+			vector.StrokeRect(screen, x+1, y+1, w-2, h-2, 2, clr, false)
+		} else {
+			vector.DrawFilledRect(screen, x, y, w, h, clr, false)
+		}
+
+		// THE FAR END OF ITS PATH
+		if o.Moving() {
+			vector.StrokeRect(screen, x+float32(o.MoveX), y+float32(o.MoveY), w, h, 1, clr, false)
+			vector.StrokeLine(screen, x+w/2, y+h/2, x+w/2+float32(o.MoveX), y+h/2+float32(o.MoveY), 1, clr, false)
+		}
+
+		if err := o.Check(); err != nil {
+			vector.StrokeRect(screen, x-2, y-2, w+4, h+4, 2, color.RGBA{230, 90, 90, 255}, false)
+		}
+
+		if i == e.selected {
+			vector.StrokeRect(screen, x-3, y-3, w+6, h+6, 1, color.White, false)
+			vector.DrawFilledRect(screen, x+w-editorHandle, y+h-editorHandle, editorHandle, editorHandle, color.White, false)
+		}
+	}
+
+	// HUD
+	titleStr := "Editing: " + e.arena.Name
+	if e.dirty {
+		titleStr += " *"
+	}
+	text.Draw(screen, titleStr, basicfont.Face7x13, 10, 20, color.White)
+
+	kindStr := fmt.Sprintf("Placing: %v", e.kind)
+	text.Draw(screen, kindStr, basicfont.Face7x13, screenWidth-120, 20, color.RGBA{150, 200, 200, 1})
+
+	if e.message != "" {
+		msg := e.message
+		if len(msg) > 88 {
+			msg = msg[:88]
+		}
+		text.Draw(screen, msg, basicfont.Face7x13, 10, 40, color.RGBA{250, 200, 60, 255})
+	}
+
+	help := []string{
+		"Drag - Draw / Move    Corner - Resize    Right Click / Del - Remove",
+		"1 Wall  2 Bumper  3 Portal    M - Movement    S - Save    Esc - Menu",
+	}
+
+	for i, line := range help {
+		text.Draw(screen, line, basicfont.Face7x13, (screenWidth-len(line)*7)/2, screenHeight-30+i*16, color.RGBA{100, 200, 250, 1})
+	}
+
+	if len(obstacles) > 0 && countPortals(obstacles)%2 != 0 {
+		text.Draw(screen, "A portal has no partner yet", basicfont.Face7x13, 10, 60, color.RGBA{230, 90, 90, 255})
+	}
+}
+
+func countPortals(obstacles []sim.Obstacle) int {
+
+	n := 0
+
+	for _, o := range obstacles {
+		if o.Kind == sim.Portal {
+			n++
+		}
+	}
+
+	return n
+}
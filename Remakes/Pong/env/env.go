@@ -0,0 +1,220 @@
+// Package env wraps Pong's simulation in a gym-style environment, Reset
+// and Step, so paddle agents can be trained and benchmarked without a
+// window or a display. Only the sim package is used, never ebiten.
+package env
+
+import (
+	"math"
+
+	"pong/sim"
+)
+
+// ACTIONS
+type Action int
+
+const (
+	Stay Action = iota
+	Up
+	Down
+	NumActions
+)
+
+// ObservationSize is the length of every observation.
+//
+// An observation is, in order: the agent's paddle centre, the opponent's
+// paddle centre, the ball's position, the ball's velocity, the distance
+// along the court from the agent's paddle to the ball and how far the
+// paddle's centre sits below the ball's. Positions are scaled to [-1, 1]
+// and velocities by the ball's top speed. The court is
+// mirrored when the agent plays on the left, so a policy always sees
+// itself on the right and can play either side.
+const ObservationSize = 8
+
+// DEFAULTS
+const (
+	DefaultFrameSkip = 4
+	DefaultMaxSteps  = 5000
+	DefaultHitReward = 0.1
+)
+
+// OPTIONS
+type Options struct {
+	Side      sim.Side       // paddle the agent plays, Right unless set otherwise
+	Opponent  sim.Controller // plays the other paddle, a Normal CPU if nil
+	Target    int            // points that end an episode
+	FrameSkip int            // simulation steps each action is held for
+	MaxSteps  int            // env steps before an episode is cut short
+	HitReward float64        // reward for returning the ball, on top of +1/-1 per point
+	PowerUps  bool
+	Seed      uint64
+}
+
+// ENV
+type Env struct {
+	opts  Options
+	world *sim.World
+	steps int
+	seed  uint64
+}
+
+// New creates an environment; call Reset before the first Step.
+func New(opts Options) *Env {
+
+	if opts.Side != sim.Left {
+		opts.Side = sim.Right
+	}
+
+	if opts.Opponent == nil {
+		opts.Opponent = sim.NewCPU(sim.Normal, opts.Seed+1)
+	}
+
+	if opts.FrameSkip <= 0 {
+		opts.FrameSkip = DefaultFrameSkip
+	}
+
+	if opts.MaxSteps <= 0 {
+		opts.MaxSteps = DefaultMaxSteps
+	}
+
+	if opts.HitReward == 0 {
+		opts.HitReward = DefaultHitReward
+	}
+
+	return &Env{opts: opts, seed: opts.Seed}
+}
+
+// Reset starts a new episode and returns its first observation. Every
+// episode gets its own seed, so serves differ between episodes but a run
+// started from the same Options is always the same.
+func (e *Env) Reset() []float64 {
+
+	e.seed++
+
+	e.world = sim.NewWorld(sim.Config{
+		Dt:       sim.DefaultStep,
+		Target:   e.opts.Target,
+		Seed:     e.seed,
+		PowerUps: e.opts.PowerUps,
+	})
+
+	e.steps = 0
+
+	return Observe(e.world, e.opts.Side)
+}
+
+// Step holds action for FrameSkip simulation steps and returns what the
+// agent sees afterwards, the reward earned and whether the episode is over.
+func (e *Env) Step(action Action) ([]float64, float64, bool) {
+
+	w := e.world
+	me := e.opts.Side
+	them := me.Opponent()
+
+	reward := 0.0
+
+	for i := 0; i < e.opts.FrameSkip && w.Phase != sim.MatchOver; i++ {
+
+		score := w.Score
+		rally := w.Rally
+
+		inputs := [2]sim.Input{}
+		inputs[me] = ActionInput(action)
+		inputs[them] = e.opts.Opponent.Control(w, them)
+
+		w.Step(inputs)
+
+		reward += float64(w.Score[me]-score[me]) - float64(w.Score[them]-score[them])
+
+		// THE RALLY GROWS BY ONE FOR EVERY RETURN, OURS ARE THE ONES
+		// THAT LEAVE THE BALL HEADING AT THE OPPONENT
+		if w.Rally > rally && ballHeadingAway(w, me) {
+			reward += e.opts.HitReward
+		}
+	}
+
+	e.steps++
+
+	done := w.Phase == sim.MatchOver || e.steps >= e.opts.MaxSteps
+
+	return Observe(w, me), reward, done
+}
+
+// World gives read access to the simulation, e.g. to render an episode.
+func (e *Env) World() *sim.World {
+
+	return e.world
+}
+
+// ActionInput is the paddle input for action.
+func ActionInput(a Action) sim.Input {
+
+	return sim.Input{Up: a == Up, Down: a == Down}
+}
+
+// Observe builds the observation for the paddle on side.
+func Observe(w *sim.World, side sim.Side) []float64 {
+
+	me := w.Paddles[side]
+	them := w.Paddles[side.Opponent()]
+	ball := nearestBall(w, me)
+
+	scaleX := func(x float64) float64 { return x/sim.Width*2 - 1 }
+	scaleY := func(y float64) float64 { return y/sim.Height*2 - 1 }
+
+	obs := []float64{
+		scaleY(me.Y + me.H/2),
+		scaleY(them.Y + them.H/2),
+		scaleX(ball.X + ball.W/2),
+		scaleY(ball.Y + ball.H/2),
+		ball.VX / sim.MaxBallSpeed,
+		ball.VY / sim.MaxBallSpeed,
+		math.Abs(me.X-ball.X) / sim.Width,
+		((me.Y + me.H/2) - (ball.Y + ball.H/2)) / sim.Height,
+	}
+
+	// MIRROR THE COURT SO THE AGENT IS ALWAYS ON THE RIGHT
+	if side == sim.Left {
+		obs[2] = -obs[2]
+		obs[4] = -obs[4]
+	}
+
+	return obs
+}
+
+// nearestBall is the ball closest to paddle p along the court, preferring
+// balls heading its way.
+func nearestBall(w *sim.World, p sim.Paddle) sim.Ball {
+
+	best := w.Balls[0]
+	bestDist := math.Inf(1)
+
+	for _, b := range w.Balls {
+
+		dist := math.Abs(p.X - b.X)
+
+		// BALLS GOING AWAY COUNT AS A FULL COURT FURTHER
+		if b.VX == 0 || (b.VX > 0) != (p.X > b.X) {
+			dist += sim.Width
+		}
+
+		if dist < bestDist {
+			best = b
+			bestDist = dist
+		}
+	}
+
+	return best
+}
+
+func ballHeadingAway(w *sim.World, side sim.Side) bool {
+
+	p := w.Paddles[side]
+
+	for _, b := range w.Balls {
+		if b.VX != 0 && (b.VX > 0) == (p.X < b.X) && b.Owner == side {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,157 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"pong/sim"
+)
+
+// A Policy picks an action from an observation. Anything trained against
+// Env can be wrapped as one and played in the game through an Agent.
+type Policy interface {
+	Act(obs []float64) Action
+}
+
+// LinearPolicy scores every action as a weighted sum of the observation
+// plus a bias and takes the best one. It is small enough to train with
+// simple search methods and to store as a few lines of JSON:
+//
+//	{"weights": [[...8 numbers...], [...], [...]], "bias": [0, 0, 0]}
+//
+// with one row of weights per action, in the order Stay, Up, Down.
+type LinearPolicy struct {
+	Weights [NumActions][ObservationSize]float64 `json:"weights"`
+	Bias    [NumActions]float64                  `json:"bias"`
+}
+
+// Act returns the highest scoring action, Stay on a tie.
+func (p *LinearPolicy) Act(obs []float64) Action {
+
+	best := Stay
+	bestScore := 0.0
+
+	for a := Stay; a < NumActions; a++ {
+
+		score := p.Bias[a]
+		for i, w := range p.Weights[a] {
+			score += w * obs[i]
+		}
+
+		if a == Stay || score > bestScore {
+			best = a
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// LoadLinear reads a LinearPolicy from a JSON file.
+func LoadLinear(path string) (*LinearPolicy, error) {
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &LinearPolicy{}
+
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return p, nil
+}
+
+// Save writes the policy as JSON, ready for LoadLinear.
+func (p *LinearPolicy) Save(path string) error {
+
+	data, err := json.MarshalIndent(p, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Agent lets a Policy play a paddle in the game. It sees what the env
+// would show it and, like in training, holds each action for FrameSkip
+// simulation steps.
+type Agent struct {
+	Policy    Policy
+	FrameSkip int
+	action    Action
+	held      int
+}
+
+// NewAgent wraps policy with the default frame skip.
+func NewAgent(policy Policy) *Agent {
+
+	return &Agent{Policy: policy, FrameSkip: DefaultFrameSkip}
+}
+
+// Control implements sim.Controller.
+func (a *Agent) Control(w *sim.World, side sim.Side) sim.Input {
+
+	if a.held <= 0 {
+		a.action = a.Policy.Act(Observe(w, side))
+		a.held = max(a.FrameSkip, 1)
+	}
+
+	a.held--
+
+	return ActionInput(a.action)
+}
+
+// RESULTS OF AN EVALUATION
+type Result struct {
+	Episodes   int
+	Wins       int
+	PointsFor  int
+	PointsAway int
+	Reward     float64 // mean per episode
+}
+
+// Evaluate plays episodes with policy and sums up how it did, resetting
+// the env before each one. The episodes carry on from the env's seed and
+// its opponent's state, so a second call on the same env plays different
+// matches; to compare policies fairly, give each a fresh env made from
+// the same Options, with a fresh opponent.
+func Evaluate(e *Env, policy Policy, episodes int) Result {
+
+	r := Result{Episodes: episodes}
+
+	for i := 0; i < episodes; i++ {
+
+		obs := e.Reset()
+		done := false
+
+		for !done {
+
+			var reward float64
+
+			obs, reward, done = e.Step(policy.Act(obs))
+			r.Reward += reward
+		}
+
+		w := e.World()
+		me := e.opts.Side
+
+		r.PointsFor += w.Score[me]
+		r.PointsAway += w.Score[me.Opponent()]
+
+		if w.Phase == sim.MatchOver && w.Winner == me {
+			r.Wins++
+		}
+	}
+
+	if episodes > 0 {
+		r.Reward /= float64(episodes)
+	}
+
+	return r
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"pong/sim"
+	"pong/vfx"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// EFFECTS
+const (
+	teleportJump = 50 // a ball moving further than this in one step went through a portal
+	maxFxStep    = 0.1
+)
+
+var (
+	netColor    = color.RGBA{90, 90, 110, 255}
+	hitColor    = color.RGBA{255, 255, 255, 255}
+	bounceColor = color.RGBA{150, 200, 200, 255}
+	goalColor   = color.RGBA{250, 140, 60, 255}
+)
+
+// watch compares the world before and after a step and sets off effects
+// for what happened in it. The simulation knows nothing about effects, so
+// they can be switched off without touching replays or online play.
+func (g *Game) watch(before, after *sim.World) {
+
+	if !g.settings.Effects {
+		return
+	}
+
+	// A SCORED POINT SHAKES THE SCREEN, SPRAYING OUT FROM WHERE THE BALL LEFT
+	for _, side := range []sim.Side{sim.Left, sim.Right} {
+
+		if after.Score[side] <= before.Score[side] || len(before.Balls) == 0 {
+			continue
+		}
+
+		// LEFT SCORES OFF THE RIGHT EDGE, AND THE OTHER WAY ROUND
+		x, angle := 0.0, 0.0
+		if side == sim.Left {
+			x, angle = sim.Width, math.Pi
+		}
+
+		y, nearest := 0.0, math.Inf(1)
+		for _, b := range before.Balls {
+			if d := math.Abs(b.X + b.W/2 - x); d < nearest {
+				y, nearest = b.Y, d
+			}
+		}
+
+		g.fx.Spray(x, y+sim.BallSize/2, 40, goalColor, 400, angle, math.Pi/3)
+		g.fx.Shake(8, 0.35)
+	}
+
+	// BALLS ARE MATCHED UP BY INDEX, SO THEIR TRAILS START AGAIN WHENEVER
+	// ONE COMES OR GOES
+	if len(before.Balls) != len(after.Balls) || after.Phase != sim.Playing {
+		for i := range max(len(before.Balls), len(after.Balls)) {
+			g.fx.Cut(i)
+		}
+	}
+
+	if after.Phase != sim.Playing || len(before.Balls) != len(after.Balls) {
+		return
+	}
+
+	for i, b := range after.Balls {
+
+		was := before.Balls[i]
+		cx, cy := b.X+b.W/2, b.Y+b.H/2
+
+		switch {
+
+		case math.Hypot(b.X-was.X, b.Y-was.Y) > teleportJump:
+			g.fx.Cut(i)
+			g.fx.Burst(was.X+was.W/2, was.Y+was.H/2, 12, bounceColor, 150)
+			g.fx.Burst(cx, cy, 12, bounceColor, 150)
+
+		case b.Hits > was.Hits:
+			g.fx.Spray(cx, cy, 18, hitColor, 300, math.Atan2(0, b.VX), math.Pi/3)
+			g.fx.Shake(2, 0.1)
+
+		case b.VY*was.VY < 0:
+			g.fx.Spray(cx, cy, 8, bounceColor, 200, math.Atan2(b.VY, 0), math.Pi/3)
+
+		case b.VX*was.VX < 0:
+			g.fx.Spray(cx, cy, 8, bounceColor, 200, math.Atan2(0, b.VX), math.Pi/3)
+		}
+
+		g.fx.Mark(i, b.X, b.Y, b.W, b.H)
+	}
+}
+
+// updateFx runs the effects on real time, whatever the simulation does.
+func (g *Game) updateFx() {
+
+	now := time.Now()
+	dt := math.Min(now.Sub(g.fxLast).Seconds(), maxFxStep)
+	g.fxLast = now
+
+	g.fx.Update(dt)
+}
+
+// drawCourtFx draws the net and the effects behind the paddles and balls.
+func (g *Game) drawCourtFx(screen *ebiten.Image) {
+
+	if !g.settings.Effects {
+		return
+	}
+
+	vfx.DashedLine(screen, screenWidth/2, 0, screenWidth/2, screenHeight, 12, 8, 2, netColor)
+	g.fx.Draw(screen, 0, 0)
+}
+
+// clearFx stops every effect, for a new match.
+func (g *Game) clearFx() {
+
+	g.fx.Clear()
+	g.fxLast = time.Now()
+}
@@ -1,9 +1,17 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image/color"
 	"log"
+	"time"
+
+	"pong/env"
+	"pong/netplay"
+	"pong/replay"
+	"pong/sim"
+	"pong/vfx"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text"
@@ -13,81 +21,140 @@ import (
 
 // CONSTANTS
 const (
-	screenWidth  = 640
-	screenHeight = 480
-	paddleSpeed  = 6
-	ballSpeed    = 4
+	screenWidth  = sim.Width
+	screenHeight = sim.Height
 
-	paddleWidth  = 20
-	paddleHeight = 100
+	maxTarget = 21
 )
 
-// GENERIC OBJECT STRUCT
-type Object struct {
-	X, Y, W, H int
-}
+// GAME STATES
+type State int
 
-// PADDLE
-type Paddle struct {
-	Object
-}
-
-// BALL
-type Ball struct {
-	Object
-	x_speed int
-	y_speed int
-}
+const (
+	stateMenu State = iota
+	stateMatch
+	stateBreakout
+	stateStats
+	stateConnecting
+	stateEditor
+	stateQuadSetup
+	stateQuad
+	stateSettings
+)
 
 // GAME
 type Game struct {
-	paddle_1  Paddle
-	paddle_2  Paddle
-	ball      Ball
-	score     int
-	highScore int
+	world    *sim.World
+	prev     *sim.World
+	clock    sim.Clock
+	step     float64 // seconds per step from -step, the clock's unless a host or a replay sets its own
+	last     time.Time
+	settings *Settings
+	powerUps bool
+	opponent sim.Controller // plays the right paddle, nil when it's a human
+	foe      string         // who the opponent is, e.g. "CPU (Hard)"
+	policy   env.Policy     // trained paddle from -policy, if any
+	state    State
+	stats    *Stats
+	recorded bool
+
+	breakout     *sim.Breakout
+	prevBreakout *sim.Breakout
+
+	quad      *sim.Quad
+	prevQuad  *sim.Quad
+	seats     [4]seat // who plays each side of a four player match, by Side
+	quadCPUs  [4]*sim.CPU
+	quadLives int
+
+	online online
+
+	recording [][2]sim.Input // inputs of the match being played, for its replay
+	playback  *replay.Replay // the replay being watched, if any
+	playStep  int
+
+	fx     *vfx.Layer
+	fxLast time.Time
+	canvas *ebiten.Image // the court is drawn here first while it shakes
+
+	paused       bool
+	pauseItem    int
+	settingsBack State // where the settings screen was opened from
+	settingsItem int
+	rebinding    bool // waiting for the key to bind to settingsItem
+
+	arenas []arenaFile
+	arena  int // index into arenas, -1 for the open court
+	editor editor
 }
 
 func main() {
 
+	tps := flag.Int("tps", ebiten.DefaultTPS, "ebiten ticks per second")
+	step := flag.Float64("step", sim.DefaultStep, "seconds simulated per fixed step")
+	policyPath := flag.String("policy", "", "JSON weights of a trained paddle to play against")
+	hostAddr := flag.String("host", "", "host online matches on this address, e.g. :7777")
+	joinAddr := flag.String("join", "", "join the online match hosted at this address, e.g. 192.168.1.20:7777")
+	delay := flag.Int("delay", netplay.DefaultDelay, "frames of input delay when hosting online")
+	rollback := flag.Bool("rollback", false, "online, predict the other player and roll back when wrong instead of waiting")
+	replayPath := flag.String("replay", "", "watch a replay file")
+	flag.Parse()
+
+	if *hostAddr != "" && *joinAddr != "" {
+		log.Fatal("-host and -join can't be used together")
+	}
+
+	// THE WORLD WOULD QUIETLY SWAP A STEP OF 0 FOR ITS OWN, LEAVING THE CLOCK BEHIND
+	if !(*step >= sim.MinStep) {
+		log.Fatalf("-step must be at least %v seconds", sim.MinStep)
+	}
+
 	ebiten.SetWindowTitle("Pong - The First")
 	ebiten.SetWindowSize(screenWidth, screenHeight)
+	ebiten.SetTPS(*tps)
 
-	paddle_1 := Paddle{
-		Object: Object{
-			X: 20,
-			Y: 190,
-			W: paddleWidth,
-			H: paddleHeight,
+	game := &Game{
+		world:     nil,
+		clock:     sim.Clock{Dt: *step},
+		step:      *step,
+		settings:  LoadSettings(settingsPath()),
+		powerUps:  false,
+		opponent:  nil,
+		state:     stateMenu,
+		stats:     LoadStats(statsPath()),
+		recorded:  false,
+		fx:        vfx.New(uint64(time.Now().UnixNano())),
+		canvas:    ebiten.NewImage(screenWidth, screenHeight),
+		seats:     [4]seat{seatHuman, seatNormal, seatNormal, seatNormal},
+		quadLives: sim.DefaultQuadLives,
+		arenas:    loadArenas(arenaDir),
+		arena:     -1,
+		online: online{
+			hostAddr: *hostAddr,
+			joinAddr: *joinAddr,
+			delay:    min(max(*delay, 0), 255),
+			rollback: *rollback,
 		},
 	}
 
-	paddle_2 := Paddle{
-		Object: Object{
-			X: 600,
-			Y: 190,
-			W: paddleWidth,
-			H: paddleHeight,
-		},
-	}
+	if *policyPath != "" {
+		policy, err := env.LoadLinear(*policyPath)
 
-	ball := Ball{
-		Object: Object{
-			X: 310,
-			Y: 230,
-			W: 20,
-			H: 20,
-		},
-		x_speed: ballSpeed,
-		y_speed: ballSpeed,
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		game.policy = policy
 	}
 
-	game := &Game{
-		paddle_1:  paddle_1,
-		paddle_2:  paddle_2,
-		ball:      ball,
-		score:     0,
-		highScore: 0,
+	if *replayPath != "" {
+		r, err := replay.Load(*replayPath)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		game.startReplay(r)
 	}
 
 	err := ebiten.RunGame(game)
@@ -99,114 +166,217 @@ func main() {
 
 func (g *Game) Update() error {
 
-	// PADDLE MOVEMENTS
-	g.paddle_1.MoveonKeyPress()
-	g.paddle_2.MoveonKeyPress()
+	if g.paused && g.state != stateSettings {
+		g.updatePause()
+		return nil
+	}
+
+	switch g.state {
+
+	case stateMenu:
+		g.updateMenu()
+
+	case stateMatch:
+		g.updateFx()
+
+		if g.online.peer != nil {
+			g.updateOnline()
+		} else {
+			g.updateMatch()
+		}
+
+	case stateBreakout:
+		g.updateBreakout()
 
-	// BALL FUNCTIONS
-	g.ball.move()
-	g.ball.collisionWithWalls(g)
+	case stateStats:
+		g.updateStats()
 
-	//COLLISION WITH PADDLES
-	g.collisionWithPaddles()
+	case stateConnecting:
+		g.updateConnecting()
+
+	case stateEditor:
+		g.updateEditor()
+
+	case stateQuadSetup:
+		g.updateQuadSetup()
+
+	case stateQuad:
+		g.updateQuad()
+
+	case stateSettings:
+		g.updateSettings()
+	}
 
 	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
 
-	// PADDLE_1
-	vector.DrawFilledRect(screen, float32(g.paddle_1.X), float32(g.paddle_1.Y), float32(g.paddle_1.W), float32(g.paddle_1.H), color.White, false)
+	g.drawState(screen)
 
-	// PADDLE_2
-	vector.DrawFilledRect(screen, float32(g.paddle_2.X), float32(g.paddle_2.Y), float32(g.paddle_2.W), float32(g.paddle_2.H), color.White, false)
+	if g.paused && g.state != stateSettings {
+		g.drawPause(screen)
+	}
+}
 
-	// BALL
-	vector.DrawFilledRect(screen, float32(g.ball.X), float32(g.ball.Y), float32(g.ball.W), float32(g.ball.H), color.White, false)
+// drawState draws whatever screen the game is on.
+func (g *Game) drawState(screen *ebiten.Image) {
 
-	// SCORE AND HIGHSCORE TEXTS
-	scoreStr := fmt.Sprintf("Score: %v", g.score)
-	text.Draw(screen, scoreStr, basicfont.Face7x13, 10, 10, color.RGBA{100, 200, 250, 1})
+	switch g.state {
 
-	highscoreStr := fmt.Sprintf("High Score: %v", g.highScore)
-	text.Draw(screen, highscoreStr, basicfont.Face7x13, 10, 30, color.RGBA{150, 200, 200, 1})
-}
+	case stateMenu:
+		g.drawMenu(screen)
+		return
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	case stateBreakout:
+		g.drawBreakout(screen)
+		return
 
-	return screenWidth, screenHeight
-}
+	case stateStats:
+		g.drawStats(screen)
+		return
+
+	case stateConnecting:
+		g.drawConnecting(screen)
+		return
 
-func (p *Paddle) MoveonKeyPress() {
+	case stateEditor:
+		g.drawEditor(screen)
+		return
 
-	if ebiten.IsKeyPressed(ebiten.KeyArrowUp) && p.Y >= 0 {
-		p.Y -= paddleSpeed
+	case stateQuadSetup:
+		g.drawQuadSetup(screen)
+		return
+
+	case stateQuad:
+		g.drawQuad(screen)
+		return
+
+	case stateSettings:
+		g.drawSettings(screen)
+		return
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyArrowDown) && (p.Y <= screenHeight-p.H) {
-		p.Y += paddleSpeed
+	if !g.settings.Effects || !g.fx.Shaking() {
+		g.drawCourt(screen)
+		return
 	}
-}
 
-func (b *Ball) move() {
+	// THE WHOLE COURT SHAKES, SCORES AND ALL
+	g.canvas.Clear()
+	g.drawCourt(g.canvas)
 
-	b.X += b.x_speed
-	b.Y += b.y_speed
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(g.fx.Offset())
+	screen.DrawImage(g.canvas, op)
 }
 
-func (g *Game) reset() {
+// drawCourt draws a Pong match: the arena, paddles, balls and scores.
+func (g *Game) drawCourt(screen *ebiten.Image) {
 
-	g.ball.X = 310
-	g.ball.Y = 230
+	w := g.world
 
-	g.score = 0
+	// NOTHING IS INTERPOLATED ACROSS A CHANGE OF PHASE, SINCE THE BALL
+	// JUMPS BACK TO THE MIDDLE WHEN A POINT IS SCORED
+	samePhase := g.prev.Phase == w.Phase
 
-}
+	// NET AND EFFECTS, THEN THE ARENA
+	g.drawCourtFx(screen)
+	g.drawObstacles(screen, samePhase)
 
-func (b *Ball) collisionWithWalls(g *Game) {
+	// PADDLES
+	for i := range w.Paddles {
+		g.drawObject(screen, g.prev.Paddles[i].Object, w.Paddles[i].Object, samePhase)
+	}
+
+	// BALLS
+	for i, b := range w.Balls {
 
-	if b.X <= ballSpeed {
-		g.reset()
+		prev := b.Object
+		if i < len(g.prev.Balls) {
+			prev = g.prev.Balls[i].Object
+		}
+
+		g.drawObject(screen, prev, b.Object, samePhase)
 	}
 
-	if b.X >= screenWidth-b.W-ballSpeed {
-		g.reset()
+	// POWER-UPS
+	g.drawPickups(screen)
+	g.drawEffects(screen)
+
+	// SCORES
+	leftStr := fmt.Sprintf("%v", w.Score[sim.Left])
+	text.Draw(screen, leftStr, basicfont.Face7x13, screenWidth/2-40, 30, color.White)
+
+	rightStr := fmt.Sprintf("%v", w.Score[sim.Right])
+	text.Draw(screen, rightStr, basicfont.Face7x13, screenWidth/2+33, 30, color.White)
+
+	// RALLY AND HIGHSCORE TEXTS
+	rallyStr := fmt.Sprintf("Rally: %v", w.Rally)
+	text.Draw(screen, rallyStr, basicfont.Face7x13, 10, 10, color.RGBA{100, 200, 250, 1})
+
+	highscoreStr := fmt.Sprintf("Longest Rally: %v", max(w.LongestRally, g.stats.LongestRally()))
+	text.Draw(screen, highscoreStr, basicfont.Face7x13, 10, 30, color.RGBA{150, 200, 200, 1})
+
+	matchStr := fmt.Sprintf("First to %v", w.Target)
+	text.Draw(screen, matchStr, basicfont.Face7x13, screenWidth-90, 30, color.RGBA{150, 200, 200, 1})
+
+	if g.opponent != nil {
+		text.Draw(screen, g.foe, basicfont.Face7x13, screenWidth-90, 10, color.RGBA{150, 200, 200, 1})
+	}
+
+	switch w.Phase {
+
+	case sim.Serving:
+		text.Draw(screen, "Get Ready!", basicfont.Face7x13, screenWidth/2-35, screenHeight/2-30, color.White)
+		drawCountdown(screen, w.ServeTimer)
+
+	case sim.MatchOver:
+		g.drawMatchOver(screen)
 	}
 
-	if b.Y <= ballSpeed {
-		b.y_speed = -b.y_speed
+	if g.online.peer != nil {
+		g.drawOnline(screen)
 	}
 
-	if b.Y >= screenHeight-b.H-ballSpeed {
-		b.y_speed = -b.y_speed
+	if g.playback != nil {
+		g.drawReplay(screen)
 	}
 }
 
-func (g *Game) collisionWithPaddles() {
+// drawObject draws obj where it is between the last two simulation steps,
+// so movement stays smooth whatever the step size and TPS are. Without
+// interpolate it is drawn exactly where the simulation left it.
+func (g *Game) drawObject(screen *ebiten.Image, prev, obj sim.Object, interpolate bool) {
 
-	//PADDLE 1
-	if g.ball.X <= g.paddle_1.X+paddleWidth &&
-		(g.ball.X+g.ball.W >= g.paddle_1.X) &&
-		(g.ball.Y+g.ball.H >= g.paddle_1.Y) &&
-		(g.ball.Y <= (g.paddle_1.Y + paddleHeight)) {
-		g.ball.X = g.paddle_1.X + paddleWidth + 1
-		g.ball.x_speed = -g.ball.x_speed
-		g.score++
+	x, y := obj.X, obj.Y
+
+	if interpolate {
+		alpha := g.clock.Alpha()
+		x = prev.X + (obj.X-prev.X)*alpha
+		y = prev.Y + (obj.Y-prev.Y)*alpha
 	}
 
-	//PADDLE 2
-	if (g.ball.X+g.ball.W >= g.paddle_2.X) &&
-		(g.ball.X <= g.paddle_2.X+paddleWidth) &&
-		(g.ball.Y <= g.paddle_2.Y+paddleHeight) &&
-		(g.ball.Y+g.ball.H >= g.paddle_2.Y) {
-		g.ball.X = g.paddle_2.X - paddleWidth - 1
-		g.ball.x_speed = -g.ball.x_speed
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(obj.W), float32(obj.H), color.White, false)
+}
+
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 
-		g.score++
-	}
+	return screenWidth, screenHeight
+}
+
+// readInputs samples the keyboard once per Update. Every simulation step
+// run during this Update sees the same keys.
+func (g *Game) readInputs() [2]sim.Input {
 
-	//HighScore Calculation
-	if g.score > g.highScore {
-		g.highScore = g.score
+	inputs := [2]sim.Input{}
+
+	for i, keys := range g.settings.Keys {
+		inputs[i] = sim.Input{
+			Up:   ebiten.IsKeyPressed(keys[0]),
+			Down: ebiten.IsKeyPressed(keys[1]),
+		}
 	}
+
+	return inputs
 }
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"pong/env"
+	"pong/replay"
+	"pong/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// MENU
+func (g *Game) updateMenu() {
+
+	// MATCH LENGTH
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) && g.settings.Target > 1 {
+		g.settings.Target--
+		g.settings.save()
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) && g.settings.Target < maxTarget {
+		g.settings.Target++
+		g.settings.save()
+	}
+
+	// POWER-UPS
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
+		g.powerUps = !g.powerUps
+	}
+
+	// SETTINGS
+	if inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		g.openSettings()
+	}
+
+	// OPPONENT
+	keys := map[ebiten.Key]sim.Difficulty{
+		ebiten.Key1: sim.Easy,
+		ebiten.Key2: sim.Normal,
+		ebiten.Key3: sim.Hard,
+	}
+
+	for key, level := range keys {
+		if inpututil.IsKeyJustPressed(key) {
+			g.opponent = sim.NewCPU(level, uint64(time.Now().UnixNano()))
+			g.foe = fmt.Sprintf("CPU (%v)", level)
+			g.newMatch()
+			return
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.Key4) {
+		g.opponent = nil
+		g.newMatch()
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.Key6) && g.policy != nil {
+		g.opponent = env.NewAgent(g.policy)
+		g.foe = "Policy"
+		g.newMatch()
+	}
+
+	// OTHER MODES
+	if inpututil.IsKeyJustPressed(ebiten.Key5) {
+		g.newBreakout()
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.Key7) {
+		g.state = stateQuadSetup
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) && (g.online.hostAddr != "" || g.online.joinAddr != "") {
+		g.startOnline()
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		g.state = stateStats
+	}
+
+	// ARENAS
+	if inpututil.IsKeyJustPressed(ebiten.KeyA) {
+		g.nextArena()
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		g.openEditor()
+	}
+}
+
+func (g *Game) drawMenu(screen *ebiten.Image) {
+
+	text.Draw(screen, "PONG", basicfont.Face7x13, screenWidth/2-14, 100, color.White)
+
+	text.Draw(screen, "1 - vs CPU (Easy)", basicfont.Face7x13, screenWidth/2-70, 180, color.White)
+	text.Draw(screen, "2 - vs CPU (Normal)", basicfont.Face7x13, screenWidth/2-70, 200, color.White)
+	text.Draw(screen, "3 - vs CPU (Hard)", basicfont.Face7x13, screenWidth/2-70, 220, color.White)
+	text.Draw(screen, "4 - Two Players", basicfont.Face7x13, screenWidth/2-70, 240, color.White)
+	text.Draw(screen, "5 - Breakout", basicfont.Face7x13, screenWidth/2-70, 260, color.White)
+	text.Draw(screen, "7 - Four Players", basicfont.Face7x13, screenWidth/2-70, 280, color.White)
+
+	// OPTIONS THE COMMAND LINE TURNED ON
+	y := 300
+
+	if g.policy != nil {
+		text.Draw(screen, "6 - vs Policy", basicfont.Face7x13, screenWidth/2-70, y, color.White)
+		y += 20
+	}
+
+	switch {
+	case g.online.hostAddr != "":
+		text.Draw(screen, "N - Host Online Match", basicfont.Face7x13, screenWidth/2-70, y, color.White)
+	case g.online.joinAddr != "":
+		text.Draw(screen, "N - Join Online Match", basicfont.Face7x13, screenWidth/2-70, y, color.White)
+	}
+
+	targetStr := fmt.Sprintf("< First to %v >", g.settings.Target)
+	text.Draw(screen, targetStr, basicfont.Face7x13, screenWidth/2-56, 340, color.RGBA{150, 200, 200, 1})
+
+	powerStr := "P - Power-Ups: Off"
+	if g.powerUps {
+		powerStr = "P - Power-Ups: On"
+	}
+	text.Draw(screen, powerStr, basicfont.Face7x13, screenWidth/2-63, 360, color.RGBA{150, 200, 200, 1})
+
+	arenaStr := fmt.Sprintf("A - Arena: %v    E - Edit", g.arenaName())
+	text.Draw(screen, arenaStr, basicfont.Face7x13, (screenWidth-len(arenaStr)*7)/2, 380, color.RGBA{150, 200, 200, 1})
+
+	keys := g.settings.Keys
+	controlsStr := fmt.Sprintf("Left: %v/%v    Right: %v/%v", keys[0][0], keys[0][1], keys[1][0], keys[1][1])
+	text.Draw(screen, controlsStr, basicfont.Face7x13, (screenWidth-len(controlsStr)*7)/2, 415, color.RGBA{100, 200, 250, 1})
+	text.Draw(screen, "O - Settings    Tab - Stats", basicfont.Face7x13, screenWidth/2-94, 440, color.RGBA{100, 200, 250, 1})
+}
+
+// MATCH FLOW
+// Every match starts from a new world, so it can be replayed from just its
+// Config and the inputs.
+func (g *Game) newMatch() {
+
+	cfg := g.settings.matchConfig()
+	cfg.Dt = g.step
+	cfg.Seed = uint64(time.Now().UnixNano())
+	cfg.PowerUps = g.powerUps
+	cfg.Arena = g.currentArena()
+
+	g.world = sim.NewWorld(cfg)
+
+	g.prev = g.world.Clone()
+	g.recorded = false
+	g.recording = nil
+	g.playback = nil
+
+	g.clearFx()
+	g.clock = sim.Clock{Dt: cfg.Dt}
+	g.last = time.Now()
+
+	g.state = stateMatch
+}
+
+// updateMatch runs as many fixed simulation steps as the real time since
+// the last Update calls for.
+func (g *Game) updateMatch() {
+
+	if g.world.Phase == sim.MatchOver {
+		g.updateMatchOver()
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.pause()
+		return
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(g.last).Seconds()
+	g.last = now
+
+	live := g.readInputs()
+
+	for steps := g.clock.Advance(elapsed); steps > 0; steps-- {
+
+		inputs, ok := g.nextInputs(live)
+
+		if !ok {
+			break
+		}
+
+		g.prev = g.world.Clone()
+		g.world.Step(inputs)
+		g.watch(g.prev, g.world)
+	}
+
+	if g.world.Phase == sim.MatchOver && !g.recorded {
+		g.recordMatch()
+		saveReplay(replay.New(g.world.Config, g.recording))
+	}
+}
+
+// modeName is how the current Pong match shows up in the stats.
+func (g *Game) modeName() string {
+
+	if g.online.peer != nil {
+		return "Online"
+	}
+
+	if g.opponent != nil {
+		return "vs " + g.foe
+	}
+
+	return "Two Players"
+}
+
+func (g *Game) winnerName() string {
+
+	if g.world.Winner == sim.Right && g.opponent != nil {
+		return g.foe
+	}
+
+	return g.world.Winner.String() + " Player"
+}
+
+func (g *Game) recordMatch() {
+
+	w := g.world
+
+	// THE PLAYER AT THIS KEYBOARD, THE LEFT ONE WHEN TWO SHARE IT
+	me := sim.Left
+	if g.online.peer != nil {
+		me = g.online.peer.Side()
+	}
+
+	g.stats.Record(MatchRecord{
+		Date:         time.Now(),
+		Mode:         g.modeName(),
+		Left:         w.Score[sim.Left],
+		Right:        w.Score[sim.Right],
+		Winner:       g.winnerName(),
+		Duration:     float64(w.Tick) * w.Dt,
+		LongestRally: w.LongestRally,
+	}, w.Winner == me, w.Score[me])
+
+	g.recorded = true
+}
+
+// MATCH OVER
+func (g *Game) updateMatchOver() {
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) && g.online.peer == nil && g.playback == nil {
+		g.newMatch()
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.state = stateMenu
+	}
+}
+
+func (g *Game) drawMatchOver(screen *ebiten.Image) {
+
+	w := g.world
+	winStr := fmt.Sprintf("%v Wins! (%v - %v)", g.winnerName(), w.Score[sim.Left], w.Score[sim.Right])
+	text.Draw(screen, winStr, basicfont.Face7x13, (screenWidth-len(winStr)*7)/2, screenHeight/2-40, color.White)
+
+	if g.online.peer != nil || g.playback != nil {
+		text.Draw(screen, "Esc - Menu", basicfont.Face7x13, screenWidth/2-35, screenHeight/2+60, color.RGBA{150, 200, 200, 1})
+		return
+	}
+
+	text.Draw(screen, "Enter - Rematch    Esc - Menu", basicfont.Face7x13, screenWidth/2-98, screenHeight/2+60, color.RGBA{150, 200, 200, 1})
+}
@@ -0,0 +1,290 @@
+package netplay
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"pong/sim"
+)
+
+// DEFAULTS
+const (
+	DefaultDelay  = 4               // frames of input delay, about 33 ms at 120 steps a second
+	Timeout       = 5 * time.Second // silence before the other peer is given up on
+	checksumEvery = 60              // frames between checksums
+	inboxSize     = 1024
+)
+
+var (
+	ErrDisconnected = errors.New("netplay: the other player left")
+	ErrTimeout      = errors.New("netplay: lost contact with the other player")
+)
+
+// DesyncError means the two simulations no longer agree.
+type DesyncError struct {
+	Frame         int
+	Local, Remote uint64
+}
+
+func (e *DesyncError) Error() string {
+
+	return fmt.Sprintf("netplay: out of sync at frame %v (%016x here, %016x there)", e.Frame, e.Local, e.Remote)
+}
+
+// A Peer is one side of an online match, whichever way it keeps in sync.
+type Peer interface {
+	// Step plays local as this peer's input for the next frame and runs
+	// the simulation as far as it can. It reports whether a frame ran.
+	// Once it returns an error the match is over and it keeps returning it.
+	Step(local sim.Input) (bool, error)
+
+	World() *sim.World // the match as this peer sees it, only Step changes it
+	Side() sim.Side    // the paddle this peer plays
+	Frame() int        // frames simulated
+	Confirmed() int    // frames simulated with both peers' real inputs
+	Close() error      // tells the other peer we're leaving and hangs up
+
+	// Inputs is both paddles' inputs for every confirmed frame, which is
+	// all a replay of the match needs.
+	Inputs() [][2]sim.Input
+
+	// Checksums holds every checksum this peer has taken of a confirmed
+	// frame, by frame. Both peers' must agree wherever they overlap.
+	Checksums() map[int]uint64
+}
+
+// packet is a received message, or the error that ended the connection.
+type packet struct {
+	m   Message
+	err error
+}
+
+// link is what every kind of Peer shares: the connection, both players'
+// inputs and the checksums that are compared to catch a desync.
+type link struct {
+	Delay int
+
+	side   sim.Side
+	t      Transport
+	local  []sim.Input // our input for every frame, delay included
+	remote []sim.Input // theirs, as far as it has arrived without gaps
+	acked  int         // how many of our inputs the other peer has
+
+	sums   map[int]uint64 // our checksums still waiting for theirs
+	theirs map[int]uint64
+	taken  map[int]uint64 // every checksum we've taken
+
+	inbox chan packet
+	done  chan struct{}
+	heard time.Time
+	err   error
+}
+
+func newLink(t Transport, side sim.Side, delay int) link {
+
+	l := link{
+		Delay:  delay,
+		side:   side,
+		t:      t,
+		local:  make([]sim.Input, delay),
+		remote: make([]sim.Input, delay),
+		acked:  delay,
+		sums:   map[int]uint64{},
+		theirs: map[int]uint64{},
+		taken:  map[int]uint64{},
+		inbox:  make(chan packet, inboxSize),
+		done:   make(chan struct{}),
+		heard:  time.Now(),
+	}
+
+	go receive(t, l.inbox, l.done)
+
+	return l
+}
+
+// receive hands everything t delivers to inbox until it fails or done is
+// closed.
+func receive(t Transport, inbox chan<- packet, done <-chan struct{}) {
+
+	for {
+		m, err := t.Recv()
+
+		select {
+		case inbox <- packet{m, err}:
+		case <-done:
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (l *link) Side() sim.Side {
+
+	return l.side
+}
+
+// inputs pairs up both players' inputs for the first n frames.
+func (l *link) inputs(n int) [][2]sim.Input {
+
+	inputs := make([][2]sim.Input, n)
+
+	for f := range inputs {
+		inputs[f][l.side] = l.local[f]
+		inputs[f][l.side.Opponent()] = l.remote[f]
+	}
+
+	return inputs
+}
+
+func (l *link) Checksums() map[int]uint64 {
+
+	return l.taken
+}
+
+func (l *link) Close() error {
+
+	if l.err == nil {
+		l.t.Send(Message{Kind: KindBye})
+		l.err = ErrDisconnected
+	}
+
+	select {
+	case <-l.done:
+	default:
+		close(l.done)
+	}
+
+	return l.t.Close()
+}
+
+func (l *link) fail(err error) error {
+
+	l.err = err
+
+	return err
+}
+
+// queue adds local as our input for frame+Delay, unless it's already
+// there because the peer is stalled.
+func (l *link) queue(frame int, local sim.Input) {
+
+	if len(l.local) <= frame+l.Delay {
+		l.local = append(l.local, local)
+	}
+}
+
+// timedOut reports whether the other peer has been silent too long.
+func (l *link) timedOut() bool {
+
+	return time.Since(l.heard) > Timeout
+}
+
+// sendSum shares our checksum after frame frames.
+func (l *link) sendSum(frame int, sum uint64) error {
+
+	l.sums[frame] = sum
+	l.taken[frame] = sum
+
+	return l.t.Send(Message{Kind: KindChecksum, Frame: frame, Sum: sum})
+}
+
+// poll handles every message that has arrived, without waiting for more.
+func (l *link) poll() error {
+
+	for {
+		select {
+
+		case p := <-l.inbox:
+			if p.err == io.EOF {
+				return ErrDisconnected
+			}
+
+			if p.err != nil {
+				return p.err
+			}
+
+			l.heard = time.Now()
+
+			if err := l.handle(p.m); err != nil {
+				return err
+			}
+
+		default:
+			return nil
+		}
+	}
+}
+
+func (l *link) handle(m Message) error {
+
+	switch m.Kind {
+
+	case KindInput:
+		l.acked = max(l.acked, m.Ack)
+
+		// KEEP ONLY WHAT EXTENDS THE RUN WE HAVE, ANYTHING PAST A GAP
+		// COMES AGAIN IN A LATER MESSAGE
+		for i, in := range m.Inputs {
+			if m.Frame+i == len(l.remote) {
+				l.remote = append(l.remote, in)
+			}
+		}
+
+	case KindChecksum:
+		l.theirs[m.Frame] = m.Sum
+
+	case KindBye:
+		return ErrDisconnected
+
+	default:
+		return fmt.Errorf("netplay: unexpected %v", m.Kind)
+	}
+
+	return nil
+}
+
+// sendInputs sends every input the other peer hasn't confirmed yet, so a
+// lost message is made up for by the next one.
+func (l *link) sendInputs() error {
+
+	if l.acked >= len(l.local) {
+		return nil
+	}
+
+	end := min(len(l.local), l.acked+maxInputs)
+
+	return l.t.Send(Message{
+		Kind:   KindInput,
+		Frame:  l.acked,
+		Ack:    len(l.remote),
+		Inputs: l.local[l.acked:end],
+	})
+}
+
+// compare checks our checksums against every one of theirs that has a
+// match.
+func (l *link) compare() error {
+
+	for frame, remote := range l.theirs {
+
+		local, ok := l.sums[frame]
+
+		if !ok {
+			continue
+		}
+
+		if local != remote {
+			return &DesyncError{Frame: frame, Local: local, Remote: remote}
+		}
+
+		delete(l.sums, frame)
+		delete(l.theirs, frame)
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+// Package netplay runs a Pong match between two machines. Only inputs go
+// over the wire: both peers run the same deterministic simulation from the
+// same Config, and compare checksums of it now and then to catch a desync.
+package netplay
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"pong/sim"
+)
+
+// Version is bumped whenever the protocol or the simulation changes in a
+// way that would make old and new peers disagree.
+const Version = 3
+
+// maxInputs is the most inputs one message carries.
+const maxInputs = 255
+
+// MESSAGE KINDS
+type Kind uint8
+
+const (
+	KindHello    Kind = iota + 1 // host to joiner, then echoed back
+	KindInput                    // a run of the sender's inputs
+	KindChecksum                 // the sender's checksum after a frame
+	KindBye                      // the sender is leaving
+)
+
+func (k Kind) String() string {
+
+	switch k {
+	case KindHello:
+		return "hello"
+	case KindInput:
+		return "input"
+	case KindChecksum:
+		return "checksum"
+	case KindBye:
+		return "bye"
+	}
+
+	return fmt.Sprintf("kind %d", uint8(k))
+}
+
+// Hello is the handshake. The host picks the settings of the match and the
+// joiner plays them.
+type Hello struct {
+	Version uint16
+	Config  sim.Config
+	Delay   int // frames of input delay, the same for both peers
+}
+
+// Message is one unit of the protocol. Which fields mean anything depends
+// on Kind.
+type Message struct {
+	Kind  Kind
+	Hello Hello // KindHello
+
+	// KindInput: Inputs[i] is the sender's input for frame Frame+i, and
+	// Ack is how many of the receiver's frames the sender already has.
+	// KindChecksum: Sum is the sender's checksum after Frame frames.
+	Frame  int
+	Ack    int
+	Inputs []sim.Input
+	Sum    uint64
+}
+
+var errShort = errors.New("netplay: message too short")
+
+// MarshalBinary encodes m, little endian and without padding.
+func (m Message) MarshalBinary() ([]byte, error) {
+
+	data := []byte{byte(m.Kind)}
+
+	switch m.Kind {
+
+	case KindHello:
+		c := m.Hello.Config
+		data = binary.LittleEndian.AppendUint16(data, m.Hello.Version)
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(c.Dt))
+		data = binary.LittleEndian.AppendUint32(data, uint32(c.Target))
+		data = binary.LittleEndian.AppendUint64(data, c.Seed)
+		data = append(data, boolByte(c.PowerUps), byte(m.Hello.Delay))
+
+		for _, v := range []float64{c.BallSpeed, c.PaddleSize, c.ServeDelay} {
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+		}
+
+		// THE ARENA GOES AS TEXT, EMPTY FOR AN OPEN COURT
+		arena := []byte{}
+		if c.Arena != nil {
+			arena, _ = c.Arena.MarshalText()
+		}
+
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(arena)))
+		data = append(data, arena...)
+
+	case KindInput:
+		if len(m.Inputs) > maxInputs {
+			return nil, fmt.Errorf("netplay: %v inputs in one message, at most %v fit", len(m.Inputs), maxInputs)
+		}
+
+		data = binary.LittleEndian.AppendUint32(data, uint32(m.Frame))
+		data = binary.LittleEndian.AppendUint32(data, uint32(m.Ack))
+		data = append(data, byte(len(m.Inputs)))
+
+		for _, in := range m.Inputs {
+			data = append(data, in.Bits())
+		}
+
+	case KindChecksum:
+		data = binary.LittleEndian.AppendUint32(data, uint32(m.Frame))
+		data = binary.LittleEndian.AppendUint64(data, m.Sum)
+
+	case KindBye:
+
+	default:
+		return nil, fmt.Errorf("netplay: can't encode %v", m.Kind)
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a message written by MarshalBinary.
+func (m *Message) UnmarshalBinary(data []byte) error {
+
+	if len(data) < 1 {
+		return errShort
+	}
+
+	*m = Message{Kind: Kind(data[0])}
+	data = data[1:]
+
+	switch m.Kind {
+
+	case KindHello:
+		if len(data) < 2 {
+			return errShort
+		}
+
+		// ANOTHER VERSION MAY LAY THE REST OUT DIFFERENTLY, SO LEAVE IT
+		// FOR THE HANDSHAKE TO TURN DOWN
+		if v := binary.LittleEndian.Uint16(data); v != Version {
+			m.Hello.Version = v
+			return nil
+		}
+
+		if len(data) < 50 {
+			return errShort
+		}
+
+		m.Hello = Hello{
+			Version: binary.LittleEndian.Uint16(data),
+			Config: sim.Config{
+				Dt:       math.Float64frombits(binary.LittleEndian.Uint64(data[2:])),
+				Target:   int(binary.LittleEndian.Uint32(data[10:])),
+				Seed:     binary.LittleEndian.Uint64(data[14:]),
+				PowerUps: data[22] != 0,
+			},
+			Delay: int(data[23]),
+		}
+
+		m.Hello.Config.BallSpeed = math.Float64frombits(binary.LittleEndian.Uint64(data[24:]))
+		m.Hello.Config.PaddleSize = math.Float64frombits(binary.LittleEndian.Uint64(data[32:]))
+		m.Hello.Config.ServeDelay = math.Float64frombits(binary.LittleEndian.Uint64(data[40:]))
+
+		size := int(binary.LittleEndian.Uint16(data[48:]))
+		data = data[50:]
+
+		if len(data) < size {
+			return errShort
+		}
+
+		if size > 0 {
+			arena, err := sim.ParseArena("host's arena", data[:size])
+
+			if err != nil {
+				return err
+			}
+
+			m.Hello.Config.Arena = arena
+		}
+
+	case KindInput:
+		if len(data) < 9 {
+			return errShort
+		}
+
+		m.Frame = int(binary.LittleEndian.Uint32(data))
+		m.Ack = int(binary.LittleEndian.Uint32(data[4:]))
+		n := int(data[8])
+		data = data[9:]
+
+		if len(data) < n {
+			return errShort
+		}
+
+		m.Inputs = make([]sim.Input, n)
+		for i := range m.Inputs {
+			m.Inputs[i] = sim.InputFromBits(data[i])
+		}
+
+	case KindChecksum:
+		if len(data) < 12 {
+			return errShort
+		}
+
+		m.Frame = int(binary.LittleEndian.Uint32(data))
+		m.Sum = binary.LittleEndian.Uint64(data[4:])
+
+	case KindBye:
+
+	default:
+		return fmt.Errorf("netplay: unknown %v", m.Kind)
+	}
+
+	return nil
+}
+
+func boolByte(b bool) byte {
+
+	if b {
+		return 1
+	}
+
+	return 0
+}
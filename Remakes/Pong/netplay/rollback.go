@@ -0,0 +1,199 @@
+package netplay
+
+import "pong/sim"
+
+// MaxRollback is how many frames a Rollback may run ahead of the other
+// peer's inputs before it waits for them, 250 ms at 120 steps a second.
+const MaxRollback = 30
+
+// Rollback keeps two copies of a match in step without waiting on the
+// network. Frames run straight away with a guess for the other peer's
+// input: the last one it sent. When the real input turns out different,
+// the world is rewound to the snapshot before the first wrong guess and
+// those frames are played again, all within one Step.
+type Rollback struct {
+	link
+
+	world *sim.World
+	frame int         // frames simulated so far
+	used  []sim.Input // the other peer's input each frame was played with
+	sure  int         // frames up to here were played with their real input
+	next  int         // the next frame to take a checksum of
+
+	// snaps[f%len(snaps)] is the world as it was after f frames
+	snaps []*sim.World
+
+	Rollbacks  int // times the world was rewound
+	Resimulate int // frames played again because of them
+}
+
+// NewRollback starts the match in hello over t, playing side. hello.Delay
+// still applies: a frame or two of delay means fewer and shorter rollbacks.
+func NewRollback(t Transport, side sim.Side, hello Hello) *Rollback {
+
+	r := &Rollback{
+		link:  newLink(t, side, hello.Delay),
+		world: sim.NewWorld(hello.Config),
+		next:  checksumEvery,
+		snaps: make([]*sim.World, MaxRollback+2),
+	}
+
+	for i := range r.snaps {
+		r.snaps[i] = r.world.Clone()
+	}
+
+	return r
+}
+
+func (r *Rollback) World() *sim.World {
+
+	return r.world
+}
+
+func (r *Rollback) Frame() int {
+
+	return r.frame
+}
+
+func (r *Rollback) Confirmed() int {
+
+	return r.sure
+}
+
+func (r *Rollback) Inputs() [][2]sim.Input {
+
+	return r.inputs(r.sure)
+}
+
+func (r *Rollback) Step(local sim.Input) (bool, error) {
+
+	if r.err != nil {
+		return false, r.err
+	}
+
+	if err := r.poll(); err != nil {
+		return false, r.fail(err)
+	}
+
+	r.queue(r.frame, local)
+
+	if err := r.sendInputs(); err != nil {
+		return false, r.fail(err)
+	}
+
+	r.reconcile()
+
+	if err := r.checksum(); err != nil {
+		return false, r.fail(err)
+	}
+
+	// TOO FAR AHEAD TO REWIND, WAIT FOR THE OTHER PEER TO CATCH UP
+	if r.frame-len(r.remote) >= MaxRollback {
+
+		if r.timedOut() {
+			return false, r.fail(ErrTimeout)
+		}
+
+		return false, nil
+	}
+
+	r.advance()
+
+	return true, nil
+}
+
+// reconcile checks the guesses made since the last call against the
+// inputs that have arrived and plays the frames again from the first one
+// that was wrong.
+func (r *Rollback) reconcile() {
+
+	sure := min(len(r.remote), r.frame)
+
+	for f := r.sure; f < sure; f++ {
+
+		if r.used[f] != r.remote[f] {
+			r.rewind(f)
+			break
+		}
+	}
+
+	r.sure = sure
+}
+
+// rewind goes back to the snapshot after frame frames and plays forward
+// again to where the world was, with the inputs known now.
+func (r *Rollback) rewind(frame int) {
+
+	end := r.frame
+
+	r.world.Restore(r.snaps[frame%len(r.snaps)])
+	r.frame = frame
+
+	for r.frame < end {
+		r.advance()
+	}
+
+	r.Rollbacks++
+	r.Resimulate += end - frame
+}
+
+// advance snapshots the world and plays the next frame, with the other
+// peer's real input if it's in and a guess if not.
+func (r *Rollback) advance() {
+
+	f := r.frame
+
+	r.snaps[f%len(r.snaps)].Restore(r.world)
+
+	theirs := r.guess(f)
+
+	if f < len(r.used) {
+		r.used[f] = theirs
+	} else {
+		r.used = append(r.used, theirs)
+	}
+
+	inputs := [2]sim.Input{}
+	inputs[r.side] = r.local[f]
+	inputs[r.side.Opponent()] = theirs
+
+	r.world.Step(inputs)
+	r.frame++
+}
+
+// guess is the other peer's input for frame, or if it isn't in yet the
+// last one that is. Players hold keys for many frames at a time, so that
+// guess is usually right.
+func (r *Rollback) guess(frame int) sim.Input {
+
+	if frame < len(r.remote) {
+		return r.remote[frame]
+	}
+
+	if len(r.remote) > 0 {
+		return r.remote[len(r.remote)-1]
+	}
+
+	return sim.Input{}
+}
+
+// checksum shares the checksum of every frame that has become sure since
+// the last call and compares them with the other peer's.
+func (r *Rollback) checksum() error {
+
+	for r.next <= r.sure {
+
+		w := r.world
+		if r.next < r.frame {
+			w = r.snaps[r.next%len(r.snaps)]
+		}
+
+		if err := r.sendSum(r.next, w.Checksum()); err != nil {
+			return err
+		}
+
+		r.next += checksumEvery
+	}
+
+	return r.compare()
+}
@@ -0,0 +1,233 @@
+package netplay_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pong/netplay"
+	"pong/sim"
+)
+
+// TestConverge plays a match against itself in real time, a CPU on each
+// paddle, and fails if the two peers ever disagree or a hang up goes
+// unnoticed. Without network conditions the peers connect over TCP on
+// loopback, otherwise through a simulated network.
+func TestConverge(t *testing.T) {
+
+	frames := 600
+	if testing.Short() {
+		frames = 180
+	}
+
+	tests := []struct {
+		name     string
+		rollback bool
+		cond     netplay.Conditions
+	}{
+		{"lockstep over tcp", false, netplay.Conditions{}},
+		{"lockstep with latency", false, netplay.Conditions{Latency: 30 * time.Millisecond, Jitter: 10 * time.Millisecond}},
+		{"lockstep with loss", false, netplay.Conditions{Latency: 30 * time.Millisecond, Jitter: 10 * time.Millisecond, Loss: 0.1}},
+		{"rollback over tcp", true, netplay.Conditions{}},
+		{"rollback with latency", true, netplay.Conditions{Latency: 60 * time.Millisecond, Jitter: 20 * time.Millisecond}},
+		{"rollback with loss", true, netplay.Conditions{Latency: 60 * time.Millisecond, Jitter: 20 * time.Millisecond, Loss: 0.1}},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			t.Parallel()
+
+			seed := uint64(i + 1)
+			delay := netplay.DefaultDelay
+			if test.rollback {
+				delay = 1
+			}
+
+			hello := netplay.Hello{
+				Version: netplay.Version,
+				Config:  sim.Config{Dt: sim.DefaultStep, Target: 1000, Seed: seed, PowerUps: true},
+				Delay:   delay,
+			}
+
+			test.cond.Seed = int64(seed)
+
+			if err := check(t, hello, test.cond, test.rollback, frames); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func check(t *testing.T, hello netplay.Hello, cond netplay.Conditions, rollback bool, frames int) error {
+
+	var a, b netplay.Transport
+	var err error
+
+	if cond == (netplay.Conditions{Seed: cond.Seed}) {
+		a, b, err = connectTCP(hello)
+	} else {
+		a, b = netplay.Loopback(cond)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	newPeer := func(t netplay.Transport, side sim.Side) netplay.Peer {
+		if rollback {
+			return netplay.NewRollback(t, side, hello)
+		}
+		return netplay.NewSession(t, side, hello)
+	}
+
+	host := newPeer(a, sim.Left)
+	guest := newPeer(b, sim.Right)
+
+	// PLAY BOTH SIDES AT ONCE, IN REAL TIME, UNTIL BOTH ARE SURE OF EVERY
+	// FRAME THEY'RE CHECKED ON
+	start := time.Now()
+	finished := atomic.Int32{}
+	results := make(chan error, 2)
+	stalls := [2]int{}
+
+	for i, peer := range []netplay.Peer{host, guest} {
+		go func() {
+			cpu := sim.NewCPU(sim.Hard, hello.Config.Seed+uint64(i))
+			n, err := play(peer, cpu, frames, &finished)
+			stalls[i] = n
+			results <- err
+		}()
+	}
+
+	for range 2 {
+		if err := <-results; err != nil {
+			return err
+		}
+	}
+
+	w := host.World()
+	t.Logf("%v frames in %v, score %v - %v, stalls %v",
+		host.Frame(), time.Since(start).Round(time.Millisecond), w.Score[sim.Left], w.Score[sim.Right], stalls)
+
+	for _, peer := range []netplay.Peer{host, guest} {
+		if r, ok := peer.(*netplay.Rollback); ok {
+			t.Logf("%v: %v rollbacks, %v frames played again", r.Side(), r.Rollbacks, r.Resimulate)
+		}
+	}
+
+	// EVERY CHECKSUM BOTH TOOK MUST MATCH
+	agreed := 0
+
+	for frame, sum := range host.Checksums() {
+
+		other, ok := guest.Checksums()[frame]
+
+		if !ok {
+			continue
+		}
+
+		if sum != other {
+			return fmt.Errorf("peers disagree at frame %v: %016x and %016x", frame, sum, other)
+		}
+
+		agreed++
+	}
+
+	if agreed < frames/60 {
+		return fmt.Errorf("only %v checksums to compare, expected %v", agreed, frames/60)
+	}
+
+	// THE GUEST MUST NOTICE THE HOST LEAVING. A LOST BYE MEANS A TIMEOUT
+	host.Close()
+
+	deadline := time.Now().Add(netplay.Timeout + time.Second)
+
+	for time.Now().Before(deadline) {
+
+		if _, err := guest.Step(sim.Input{}); err != nil {
+
+			if !errors.Is(err, netplay.ErrDisconnected) && !errors.Is(err, netplay.ErrTimeout) {
+				return fmt.Errorf("guest saw %v, not a disconnect", err)
+			}
+
+			return nil
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	return errors.New("guest never noticed the host leaving")
+}
+
+// connectTCP hosts and joins a match over TCP on loopback.
+func connectTCP(hello netplay.Hello) (netplay.Transport, netplay.Transport, error) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer ln.Close()
+
+	// THE HOST BLOCKS UNTIL SOMEONE JOINS, SO JOIN FROM ANOTHER GOROUTINE
+	type joined struct {
+		t   netplay.Transport
+		err error
+	}
+
+	guest := make(chan joined, 1)
+
+	go func() {
+		t, _, err := netplay.Join(ln.Addr().String())
+		guest <- joined{t, err}
+	}()
+
+	host, err := netplay.Host(ln, hello)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	j := <-guest
+
+	return host, j.t, j.err
+}
+
+// play steps peer once a frame with cpu at the paddle until both peers
+// have confirmed frames frames, and returns how often it stalled.
+func play(peer netplay.Peer, cpu *sim.CPU, frames int, finished *atomic.Int32) (int, error) {
+
+	dt := peer.World().Dt
+	tick := time.NewTicker(time.Duration(dt * float64(time.Second)))
+	defer tick.Stop()
+
+	stalls := 0
+	done := false
+
+	for finished.Load() < 2 {
+
+		<-tick.C
+
+		stepped, err := peer.Step(cpu.Control(peer.World(), peer.Side()))
+
+		if err != nil {
+			return stalls, fmt.Errorf("%v peer: %w", peer.Side(), err)
+		}
+
+		if !stepped {
+			stalls++
+		}
+
+		if !done && peer.Confirmed() >= frames {
+			done = true
+			finished.Add(1)
+		}
+	}
+
+	return stalls, nil
+}
@@ -0,0 +1,88 @@
+package netplay
+
+import "pong/sim"
+
+// Session keeps two copies of a match in step with input delay: the input
+// read on frame f is played on frame f+Delay, which gives it Delay frames
+// to reach the other peer. A frame only runs once both inputs for it are
+// in, so if they're late the game stalls rather than guesses.
+type Session struct {
+	link
+	world *sim.World
+	frame int // frames simulated so far
+}
+
+// NewSession starts the match in hello over t, playing side.
+func NewSession(t Transport, side sim.Side, hello Hello) *Session {
+
+	return &Session{
+		link:  newLink(t, side, hello.Delay),
+		world: sim.NewWorld(hello.Config),
+	}
+}
+
+func (s *Session) World() *sim.World {
+
+	return s.world
+}
+
+func (s *Session) Frame() int {
+
+	return s.frame
+}
+
+// Confirmed is the same as Frame, nothing is ever guessed.
+func (s *Session) Confirmed() int {
+
+	return s.frame
+}
+
+func (s *Session) Inputs() [][2]sim.Input {
+
+	return s.inputs(s.frame)
+}
+
+func (s *Session) Step(local sim.Input) (bool, error) {
+
+	if s.err != nil {
+		return false, s.err
+	}
+
+	if err := s.poll(); err != nil {
+		return false, s.fail(err)
+	}
+
+	s.queue(s.frame, local)
+
+	if err := s.sendInputs(); err != nil {
+		return false, s.fail(err)
+	}
+
+	if len(s.remote) <= s.frame {
+
+		if s.timedOut() {
+			return false, s.fail(ErrTimeout)
+		}
+
+		return false, nil
+	}
+
+	inputs := [2]sim.Input{}
+	inputs[s.side] = s.local[s.frame]
+	inputs[s.side.Opponent()] = s.remote[s.frame]
+
+	s.world.Step(inputs)
+	s.frame++
+
+	if s.frame%checksumEvery == 0 {
+		if err := s.sendSum(s.frame, s.world.Checksum()); err != nil {
+			return true, s.fail(err)
+		}
+	}
+
+	if err := s.compare(); err != nil {
+		return true, s.fail(err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,195 @@
+package netplay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// handshakeTimeout bounds each step of connecting.
+const handshakeTimeout = 5 * time.Second
+
+// A Transport carries messages between the two peers.
+type Transport interface {
+	Send(m Message) error
+	Recv() (Message, error) // blocks until a message arrives
+	Close() error
+}
+
+// stream sends messages over a byte stream such as TCP, each one prefixed
+// by its length.
+type stream struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewStream frames messages over conn.
+func NewStream(conn net.Conn) Transport {
+
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		// INPUTS ARE TINY AND LATE ONES STALL THE GAME, DON'T BATCH THEM
+		tcp.SetNoDelay(true)
+	}
+
+	return &stream{conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (s *stream) Send(m Message) error {
+
+	data, err := m.MarshalBinary()
+
+	if err != nil {
+		return err
+	}
+
+	frame := binary.LittleEndian.AppendUint16(nil, uint16(len(data)))
+
+	_, err = s.conn.Write(append(frame, data...))
+
+	return hungUp(err)
+}
+
+func (s *stream) Recv() (Message, error) {
+
+	var size [2]byte
+
+	if _, err := io.ReadFull(s.r, size[:]); err != nil {
+		return Message{}, hungUp(err)
+	}
+
+	data := make([]byte, binary.LittleEndian.Uint16(size[:]))
+
+	if _, err := io.ReadFull(s.r, data); err != nil {
+		return Message{}, hungUp(err)
+	}
+
+	m := Message{}
+	err := m.UnmarshalBinary(data)
+
+	return m, err
+}
+
+func (s *stream) Close() error {
+
+	return s.conn.Close()
+}
+
+// hungUp makes a connection reset or aborted by the other end, rather than
+// closed cleanly to an io.EOF, ErrDisconnected too. Which error that is
+// depends on the system and on whether we were reading or writing.
+func hungUp(err error) error {
+
+	var op *net.OpError
+
+	if errors.As(err, &op) && !op.Timeout() {
+		return ErrDisconnected
+	}
+
+	return err
+}
+
+// Host waits on ln for one player to join and offers them a match. The
+// host plays the left paddle.
+func Host(ln net.Listener, hello Hello) (Transport, error) {
+
+	conn, err := ln.Accept()
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := NewStream(conn)
+
+	hello.Version = Version
+
+	if err := handshake(conn, func() error {
+
+		if err := t.Send(Message{Kind: KindHello, Hello: hello}); err != nil {
+			return err
+		}
+
+		reply, err := t.Recv()
+
+		if err != nil {
+			return err
+		}
+
+		if reply.Kind != KindHello || !sameHello(reply.Hello, hello) {
+			return errors.New("netplay: player didn't accept the match")
+		}
+
+		return nil
+	}); err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Join connects to a host at addr and returns the match it offered. The
+// joiner plays the right paddle.
+func Join(addr string) (Transport, Hello, error) {
+
+	conn, err := net.DialTimeout("tcp", addr, handshakeTimeout)
+
+	if err != nil {
+		return nil, Hello{}, err
+	}
+
+	t := NewStream(conn)
+	hello := Hello{}
+
+	if err := handshake(conn, func() error {
+
+		m, err := t.Recv()
+
+		if err != nil {
+			return err
+		}
+
+		if m.Kind != KindHello {
+			return fmt.Errorf("netplay: expected hello, got %v", m.Kind)
+		}
+
+		if m.Hello.Version != Version {
+			return fmt.Errorf("netplay: host speaks version %v, we speak %v", m.Hello.Version, Version)
+		}
+
+		hello = m.Hello
+
+		// ECHO IT BACK TO AGREE
+		return t.Send(m)
+	}); err != nil {
+		t.Close()
+		return nil, Hello{}, err
+	}
+
+	return t, hello, nil
+}
+
+// sameHello compares two hellos by their encoding, arenas included.
+func sameHello(a, b Hello) bool {
+
+	encA, errA := Message{Kind: KindHello, Hello: a}.MarshalBinary()
+	encB, errB := Message{Kind: KindHello, Hello: b}.MarshalBinary()
+
+	return errA == nil && errB == nil && bytes.Equal(encA, encB)
+}
+
+// handshake runs shake with a deadline on conn, cleared afterwards.
+func handshake(conn net.Conn, shake func() error) error {
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+
+	if err := shake(); err != nil {
+		return err
+	}
+
+	return conn.SetDeadline(time.Time{})
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"net"
+	"time"
+
+	"pong/netplay"
+	"pong/replay"
+	"pong/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// connection is the outcome of hosting or joining.
+type connection struct {
+	t     netplay.Transport
+	hello netplay.Hello
+	side  sim.Side
+	err   error
+}
+
+// ONLINE SETTINGS, FROM THE COMMAND LINE
+type online struct {
+	hostAddr string // address to host on, empty when not hosting
+	joinAddr string // address to join, empty when not joining
+	delay    int
+	rollback bool // predict the other player's input rather than wait for it
+
+	listener net.Listener
+	pending  chan connection // set while connecting
+	peer     netplay.Peer
+	stalled  bool  // the last frame waited on the other player
+	err      error // why the connection failed or ended
+}
+
+// startOnline hosts or joins in the background, whichever the command line
+// asked for, with the menu's match settings when hosting.
+func (g *Game) startOnline() {
+
+	o := &g.online
+	o.err = nil
+
+	pending := make(chan connection, 1)
+	o.pending = pending
+
+	if o.hostAddr != "" {
+
+		ln, err := net.Listen("tcp", o.hostAddr)
+
+		if err != nil {
+			o.err = err
+			o.pending = nil
+			g.state = stateConnecting
+			return
+		}
+
+		o.listener = ln
+
+		cfg := g.settings.matchConfig()
+		cfg.Dt = g.step
+		cfg.Seed = uint64(time.Now().UnixNano())
+		cfg.PowerUps = g.powerUps
+		cfg.Arena = g.currentArena()
+
+		hello := netplay.Hello{Config: cfg, Delay: o.delay}
+
+		go func() {
+			t, err := netplay.Host(ln, hello)
+			ln.Close()
+			pending <- connection{t, hello, sim.Left, err}
+		}()
+
+	} else {
+
+		addr := o.joinAddr
+
+		go func() {
+			t, hello, err := netplay.Join(addr)
+			pending <- connection{t, hello, sim.Right, err}
+		}()
+	}
+
+	g.state = stateConnecting
+}
+
+func (g *Game) updateConnecting() {
+
+	o := &g.online
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.cancelOnline()
+		g.state = stateMenu
+		return
+	}
+
+	if o.pending == nil {
+		return
+	}
+
+	select {
+
+	case c := <-o.pending:
+		o.pending = nil
+		o.listener = nil
+
+		if c.err != nil {
+			o.err = c.err
+			return
+		}
+
+		if o.rollback {
+			o.peer = netplay.NewRollback(c.t, c.side, c.hello)
+		} else {
+			o.peer = netplay.NewSession(c.t, c.side, c.hello)
+		}
+		o.stalled = false
+
+		g.opponent = nil
+		g.world = o.peer.World()
+		g.prev = g.world.Clone()
+		g.recorded = false
+
+		g.clearFx()
+
+		// THE JOINER STEPS AT THE HOST'S RATE, WHATEVER ITS OWN -step
+		g.clock = sim.Clock{Dt: g.world.Dt}
+		g.last = time.Now()
+
+		g.state = stateMatch
+
+	default:
+	}
+}
+
+// cancelOnline gives up on a connection still being made.
+func (g *Game) cancelOnline() {
+
+	o := &g.online
+
+	if o.listener != nil {
+		o.listener.Close()
+		o.listener = nil
+	}
+
+	// A JOIN MAY STILL GO THROUGH, HANG UP ON IT IF SO
+	if pending := o.pending; pending != nil {
+		go func() {
+			if c := <-pending; c.t != nil {
+				c.t.Close()
+			}
+		}()
+	}
+
+	o.pending = nil
+}
+
+// leaveOnline ends the online match.
+func (g *Game) leaveOnline() {
+
+	o := &g.online
+
+	if o.peer != nil {
+		if !g.recorded {
+			g.saveOnlineReplay()
+		}
+
+		o.peer.Close()
+		o.peer = nil
+	}
+
+	g.state = stateMenu
+}
+
+// updateOnline is updateMatch for a match against another machine. Both
+// sets of keys move the local paddle.
+func (g *Game) updateOnline() {
+
+	o := &g.online
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.leaveOnline()
+		return
+	}
+
+	if o.err != nil {
+		return
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(g.last).Seconds()
+	g.last = now
+
+	keys := g.readInputs()
+
+	in := sim.Input{
+		Up:   keys[sim.Left].Up || keys[sim.Right].Up,
+		Down: keys[sim.Left].Down || keys[sim.Right].Down,
+	}
+
+	for steps := g.clock.Advance(elapsed); steps > 0; steps-- {
+
+		prev := g.world.Clone()
+		stepped, err := o.peer.Step(in)
+
+		if err != nil {
+			o.err = err
+			break
+		}
+
+		o.stalled = !stepped
+
+		// WAITING ON THE OTHER PLAYER, THE LOST TIME IS NOT MADE UP
+		if !stepped {
+			break
+		}
+
+		g.prev = prev
+		g.watch(g.prev, g.world)
+	}
+
+	// A ROLLBACK PEER CAN SEE THE MATCH END ON A GUESS, SO KEEP STEPPING
+	// AND ONLY RECORD IT ONCE THE FINAL FRAME IS CONFIRMED
+	w := g.world
+	if w.Phase == sim.MatchOver && !g.recorded && o.peer.Confirmed() >= w.Tick {
+		g.recordMatch()
+		g.saveOnlineReplay()
+	}
+}
+
+// saveOnlineReplay saves the match as far as both players' inputs are
+// known, whether it was finished or not.
+func (g *Game) saveOnlineReplay() {
+
+	saveReplay(replay.New(g.world.Config, g.online.peer.Inputs()))
+}
+
+func (g *Game) drawConnecting(screen *ebiten.Image) {
+
+	o := &g.online
+
+	msg := fmt.Sprintf("Waiting for a player on %v...", o.hostAddr)
+	if o.hostAddr == "" {
+		msg = fmt.Sprintf("Joining %v...", o.joinAddr)
+	}
+
+	if o.err != nil {
+		msg = fmt.Sprintf("Couldn't connect: %v", o.err)
+	}
+
+	text.Draw(screen, msg, basicfont.Face7x13, max((screenWidth-len(msg)*7)/2, 10), screenHeight/2, color.White)
+	text.Draw(screen, "Esc - Menu", basicfont.Face7x13, screenWidth/2-35, screenHeight/2+60, color.RGBA{100, 200, 250, 1})
+}
+
+// drawOnline draws the online status over the match.
+func (g *Game) drawOnline(screen *ebiten.Image) {
+
+	o := &g.online
+
+	youStr := fmt.Sprintf("Online: you are %v", o.peer.Side())
+	text.Draw(screen, youStr, basicfont.Face7x13, screenWidth-140, screenHeight-10, color.RGBA{150, 200, 200, 1})
+
+	switch {
+
+	case o.err != nil:
+		errStr := fmt.Sprintf("Connection lost: %v", o.err)
+		text.Draw(screen, errStr, basicfont.Face7x13, max((screenWidth-len(errStr)*7)/2, 10), screenHeight/2+20, color.White)
+		text.Draw(screen, "Esc - Menu", basicfont.Face7x13, screenWidth/2-35, screenHeight/2+60, color.RGBA{100, 200, 250, 1})
+
+	case o.stalled:
+		text.Draw(screen, "Waiting for the other player...", basicfont.Face7x13, screenWidth/2-108, screenHeight/2+20, color.White)
+	}
+}
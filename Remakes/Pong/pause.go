@@ -0,0 +1,139 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font/basicfont"
+)
+
+// PAUSE MENU
+// Esc pauses any game played on this machine alone. Online matches can't
+// be paused, the other player's game keeps going.
+const (
+	pauseResume = iota
+	pauseRestart
+	pauseSettings
+	pauseQuit
+)
+
+var pauseItems = []string{"Resume", "Restart", "Settings", "Quit to Menu"}
+
+func (g *Game) pause() {
+
+	g.paused = true
+	g.pauseItem = pauseResume
+}
+
+func (g *Game) updatePause() {
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.resume()
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		g.pauseItem = (g.pauseItem + len(pauseItems) - 1) % len(pauseItems)
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		g.pauseItem = (g.pauseItem + 1) % len(pauseItems)
+	}
+
+	if !inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		return
+	}
+
+	switch g.pauseItem {
+
+	case pauseResume:
+		g.resume()
+
+	case pauseRestart:
+		g.paused = false
+		g.restart()
+
+	case pauseSettings:
+		g.openSettings()
+
+	case pauseQuit:
+		g.paused = false
+		g.state = stateMenu
+	}
+}
+
+// resume carries on from where the game was paused. The time spent paused
+// is skipped, not caught up on.
+func (g *Game) resume() {
+
+	g.paused = false
+
+	now := time.Now()
+	g.last = now
+	g.fxLast = now
+}
+
+// restart starts the game being played over from the beginning.
+func (g *Game) restart() {
+
+	switch g.state {
+
+	case stateMatch:
+		if g.playback != nil {
+			g.startReplay(g.playback)
+		} else {
+			g.newMatch()
+		}
+
+	case stateBreakout:
+		g.newBreakout()
+
+	case stateQuad:
+		g.newQuad()
+	}
+}
+
+func (g *Game) drawPause(screen *ebiten.Image) {
+
+	// DIM THE GAME BEHIND
+	vector.DrawFilledRect(screen, 0, 0, screenWidth, screenHeight, color.RGBA{0, 0, 0, 170}, false)
+
+	text.Draw(screen, "PAUSED", basicfont.Face7x13, screenWidth/2-21, 160, color.White)
+
+	for i, item := range pauseItems {
+
+		clr := color.RGBA{150, 200, 200, 255}
+		if i == g.pauseItem {
+			clr = color.RGBA{255, 255, 255, 255}
+			item = "> " + item + " <"
+		}
+
+		text.Draw(screen, item, basicfont.Face7x13, (screenWidth-len(item)*7)/2, 210+i*24, clr)
+	}
+
+	text.Draw(screen, "Up/Down - Choose    Enter - Select    Esc - Resume", basicfont.Face7x13, screenWidth/2-175, 400, color.RGBA{100, 200, 250, 255})
+}
+
+// drawCountdown shows the seconds left before a serve, big, in the middle
+// of the court.
+func drawCountdown(screen *ebiten.Image, timer float64) {
+
+	n := int(math.Ceil(timer))
+	if n < 1 {
+		return
+	}
+
+	digit := string(rune('0' + min(n, 9)))
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(4, 4)
+	op.GeoM.Translate(screenWidth/2-14, screenHeight/2+60)
+	op.ColorScale.ScaleWithColor(color.White)
+
+	text.DrawWithOptions(screen, digit, basicfont.Face7x13, op)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"pong/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font/basicfont"
+)
+
+var pickupColors = map[sim.PowerUp]color.RGBA{
+	sim.MultiBall:   {250, 200, 60, 255},
+	sim.BigPaddle:   {100, 220, 120, 255},
+	sim.SmallPaddle: {230, 90, 90, 255},
+	sim.Sticky:      {100, 200, 250, 255},
+	sim.Reverse:     {200, 120, 230, 255},
+}
+
+func (g *Game) drawPickups(screen *ebiten.Image) {
+
+	for _, p := range g.world.Pickups {
+
+		vector.DrawFilledRect(screen, float32(p.X), float32(p.Y), float32(p.W), float32(p.H), pickupColors[p.Kind], false)
+
+		letter := p.Kind.String()[:1]
+		text.Draw(screen, letter, basicfont.Face7x13, int(p.X+p.W/2)-3, int(p.Y+p.H/2)+5, color.Black)
+	}
+}
+
+// drawEffects lists the running effects under the paddle they act on,
+// each with the seconds it has left.
+func (g *Game) drawEffects(screen *ebiten.Image) {
+
+	rows := [2]int{}
+
+	for _, e := range g.world.Effects {
+
+		x := 10
+		if e.Target == sim.Right {
+			x = screenWidth - 130
+		}
+
+		y := screenHeight - 10 - rows[e.Target]*16
+		rows[e.Target]++
+
+		effectStr := fmt.Sprintf("%v %.0fs", e.Kind, e.Remaining)
+		text.Draw(screen, effectStr, basicfont.Face7x13, x, y, pickupColors[e.Kind])
+	}
+}
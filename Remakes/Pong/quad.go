@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"time"
+
+	"pong/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"golang.org/x/image/font/basicfont"
+)
+
+// SEATS
+// Who plays each side of a four player match.
+type seat int
+
+const (
+	seatHuman seat = iota
+	seatEasy
+	seatNormal
+	seatHard
+	seatClosed
+	seatKinds
+)
+
+func (s seat) String() string {
+
+	switch s {
+	case seatHuman:
+		return "Human"
+	case seatClosed:
+		return "Closed"
+	}
+
+	return fmt.Sprintf("CPU (%v)", s.level())
+}
+
+func (s seat) level() sim.Difficulty {
+
+	return sim.Difficulty(s - seatEasy)
+}
+
+// quadKeys are the keys of each side in four player mode, by Side: up and
+// down for the vertical paddles, left and right for the horizontal ones.
+// The left and right paddles use the keys from the settings.
+func (g *Game) quadKeys() [4][2]ebiten.Key {
+
+	return [4][2]ebiten.Key{
+		g.settings.Keys[sim.Left],
+		g.settings.Keys[sim.Right],
+		{ebiten.KeyZ, ebiten.KeyX},
+		{ebiten.KeyN, ebiten.KeyM},
+	}
+}
+
+var closedColor = color.RGBA{230, 90, 90, 255}
+
+// FOUR PLAYER SETUP
+func (g *Game) updateQuadSetup() {
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.state = stateMenu
+		return
+	}
+
+	for i, key := range []ebiten.Key{ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4} {
+		if inpututil.IsKeyJustPressed(key) {
+			g.seats[i] = (g.seats[i] + 1) % seatKinds
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) && g.quadLives > 1 {
+		g.quadLives--
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) && g.quadLives < maxTarget {
+		g.quadLives++
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) && g.seatsTaken() >= 2 {
+		g.newQuad()
+	}
+}
+
+func (g *Game) seatsTaken() int {
+
+	n := 0
+
+	for _, s := range g.seats {
+		if s != seatClosed {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (g *Game) drawQuadSetup(screen *ebiten.Image) {
+
+	text.Draw(screen, "FOUR PLAYERS", basicfont.Face7x13, screenWidth/2-42, 100, color.White)
+
+	keys := g.quadKeys()
+
+	for i, s := range g.seats {
+
+		seatStr := fmt.Sprintf("%v - %v (%v/%v): %v", i+1, sim.Side(i), keys[i][0], keys[i][1], s)
+		text.Draw(screen, seatStr, basicfont.Face7x13, screenWidth/2-120, 180+i*20, color.White)
+	}
+
+	livesStr := fmt.Sprintf("< Lives: %v >", g.quadLives)
+	text.Draw(screen, livesStr, basicfont.Face7x13, (screenWidth-len(livesStr)*7)/2, 290, color.RGBA{150, 200, 200, 1})
+
+	if g.seatsTaken() < 2 {
+		text.Draw(screen, "At least two sides have to play", basicfont.Face7x13, screenWidth/2-108, 330, closedColor)
+	}
+
+	text.Draw(screen, "Enter - Start    Esc - Menu", basicfont.Face7x13, screenWidth/2-91, 400, color.RGBA{100, 200, 250, 1})
+}
+
+// FOUR PLAYER MATCH
+func (g *Game) newQuad() {
+
+	match := g.settings.matchConfig()
+
+	cfg := sim.QuadConfig{
+		Dt:         g.step,
+		Lives:      g.quadLives,
+		Seed:       uint64(time.Now().UnixNano()),
+		BallSpeed:  match.BallSpeed,
+		PaddleSize: match.PaddleSize,
+		ServeDelay: match.ServeDelay,
+	}
+
+	for i, s := range g.seats {
+
+		cfg.Seats[i] = s != seatClosed
+		g.quadCPUs[i] = nil
+
+		if s != seatHuman && s != seatClosed {
+			g.quadCPUs[i] = sim.NewCPU(s.level(), cfg.Seed+uint64(i))
+		}
+	}
+
+	// THE SETUP ONLY STARTS A MATCH WITH TWO SEATS TAKEN, SO THIS ISN'T EXPECTED
+	quad, err := sim.NewQuad(cfg)
+
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	g.quad = quad
+	g.prevQuad = g.quad.Clone()
+	g.recorded = false
+
+	g.clock = sim.Clock{Dt: cfg.Dt}
+	g.last = time.Now()
+
+	g.state = stateQuad
+}
+
+func (g *Game) updateQuad() {
+
+	q := g.quad
+
+	if q.Phase == sim.MatchOver {
+
+		if !g.recorded {
+			g.recordQuad()
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			g.state = stateMenu
+			return
+		}
+
+		if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+			g.newQuad()
+		}
+
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.pause()
+		return
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(g.last).Seconds()
+	g.last = now
+
+	live := [4]sim.Input{}
+
+	for i, keys := range g.quadKeys() {
+
+		if sim.Side(i) == sim.Top || sim.Side(i) == sim.Bottom {
+			live[i] = sim.Input{Left: ebiten.IsKeyPressed(keys[0]), Right: ebiten.IsKeyPressed(keys[1])}
+		} else {
+			live[i] = sim.Input{Up: ebiten.IsKeyPressed(keys[0]), Down: ebiten.IsKeyPressed(keys[1])}
+		}
+	}
+
+	for steps := g.clock.Advance(elapsed); steps > 0; steps-- {
+
+		inputs := live
+
+		for i, cpu := range g.quadCPUs {
+			if cpu != nil {
+				inputs[i] = cpu.ControlQuad(q, sim.Side(i))
+			}
+		}
+
+		g.prevQuad = q.Clone()
+		q.Step(inputs)
+	}
+}
+
+// quadName is who plays side, for the winner's banner.
+func (g *Game) quadName(side sim.Side) string {
+
+	if g.seats[side] == seatHuman {
+		return side.String() + " Player"
+	}
+
+	return fmt.Sprintf("%v %v", side, g.seats[side])
+}
+
+// recordQuad saves a finished four player match. Left holds the lives the
+// winner had left and Right how many sides played.
+func (g *Game) recordQuad() {
+
+	q := g.quad
+	won := g.seats[q.Winner] == seatHuman
+
+	score := 0
+	if won {
+		score = q.LivesLeft[q.Winner]
+	}
+
+	g.stats.Record(MatchRecord{
+		Date:         time.Now(),
+		Mode:         "Four Players",
+		Left:         q.LivesLeft[q.Winner],
+		Right:        g.seatsTaken(),
+		Winner:       g.quadName(q.Winner),
+		Duration:     float64(q.Tick) * q.Dt,
+		LongestRally: q.LongestRally,
+	}, won, score)
+
+	g.recorded = true
+}
+
+func (g *Game) drawQuad(screen *ebiten.Image) {
+
+	q := g.quad
+	samePhase := g.prevQuad.Phase == q.Phase
+
+	// CORNERS
+	for _, c := range sim.QuadCorners {
+		vector.DrawFilledRect(screen, float32(c.X), float32(c.Y), float32(c.W), float32(c.H), color.RGBA{60, 60, 80, 255}, false)
+	}
+
+	// PADDLES, OR THE WALL ACROSS THE GOAL OF A SIDE THAT'S OUT
+	walls := [4][4]float32{
+		{0, 0, 4, screenHeight},
+		{screenWidth - 4, 0, 4, screenHeight},
+		{0, 0, screenWidth, 4},
+		{0, screenHeight - 4, screenWidth, 4},
+	}
+
+	// WHERE EACH SIDE'S LIVES ARE SHOWN
+	labels := [4][2]int{
+		{70, screenHeight / 2},
+		{screenWidth - 110, screenHeight / 2},
+		{screenWidth/2 - 20, 75},
+		{screenWidth/2 - 20, screenHeight - 65},
+	}
+
+	for i, p := range q.Paddles {
+
+		side := sim.Side(i)
+
+		if !q.In(side) {
+			wall := walls[i]
+			vector.DrawFilledRect(screen, wall[0], wall[1], wall[2], wall[3], closedColor, false)
+			continue
+		}
+
+		g.drawObject(screen, g.prevQuad.Paddles[i].Object, p.Object, samePhase)
+
+		livesStr := fmt.Sprintf("%v %v", side, q.LivesLeft[i])
+		text.Draw(screen, livesStr, basicfont.Face7x13, labels[i][0], labels[i][1], color.RGBA{150, 200, 200, 1})
+	}
+
+	// BALL
+	g.drawObject(screen, g.prevQuad.Ball.Object, q.Ball.Object, samePhase)
+
+	switch q.Phase {
+
+	case sim.Serving:
+		text.Draw(screen, "Get Ready!", basicfont.Face7x13, screenWidth/2-35, screenHeight/2-30, color.White)
+		drawCountdown(screen, q.ServeTimer)
+
+	case sim.MatchOver:
+		winStr := fmt.Sprintf("%v Wins!", g.quadName(q.Winner))
+		text.Draw(screen, winStr, basicfont.Face7x13, (screenWidth-len(winStr)*7)/2, screenHeight/2-40, color.White)
+		text.Draw(screen, "Enter - Rematch    Esc - Menu", basicfont.Face7x13, screenWidth/2-98, screenHeight/2+60, color.RGBA{150, 200, 200, 1})
+	}
+}
@@ -0,0 +1,269 @@
+// Package replay stores a Pong match as the Config it started from and the
+// inputs of both paddles on every step. The simulation is deterministic,
+// so that is enough to play the match again exactly, to watch it, to chase
+// a bug a player ran into, or to check that a change to the physics left
+// recorded matches alone.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"pong/sim"
+)
+
+// Ext is the file extension of replays.
+const Ext = ".pongreplay"
+
+// magic starts every replay file, the last byte is the format version.
+var magic = []byte("PONGRPL\x01")
+
+// REPLAY
+type Replay struct {
+	Config   sim.Config
+	Inputs   [][2]sim.Input // both paddles' inputs, step by step
+	Checksum uint64         // the world's checksum after the last step
+	Score    [2]int         // the score after the last step, for show
+}
+
+// New records a match that started as sim.NewWorld(cfg) and was stepped
+// with inputs. It plays the match through once to fill in how it ended.
+func New(cfg sim.Config, inputs [][2]sim.Input) *Replay {
+
+	r := &Replay{Config: cfg, Inputs: inputs}
+
+	w := r.Run()
+	r.Checksum = w.Checksum()
+	r.Score = w.Score
+
+	return r
+}
+
+// Run plays the whole replay headless and returns where it ended up.
+func (r *Replay) Run() *sim.World {
+
+	w := sim.NewWorld(r.Config)
+
+	for _, inputs := range r.Inputs {
+		w.Step(inputs)
+	}
+
+	return w
+}
+
+// MismatchError means a replay no longer plays out the way it was recorded,
+// so the simulation has changed since.
+type MismatchError struct {
+	Want, Got uint64
+}
+
+func (e *MismatchError) Error() string {
+
+	return fmt.Sprintf("replay: ended with checksum %016x, recorded %016x", e.Got, e.Want)
+}
+
+// Verify plays the replay and checks it ends where it did when recorded.
+func (r *Replay) Verify() error {
+
+	if got := r.Run().Checksum(); got != r.Checksum {
+		return &MismatchError{Want: r.Checksum, Got: got}
+	}
+
+	return nil
+}
+
+// MarshalBinary encodes the replay. Inputs are run length encoded, since
+// keys are held down for many steps at a time.
+func (r *Replay) MarshalBinary() ([]byte, error) {
+
+	data := append([]byte(nil), magic...)
+
+	c := r.Config
+	data = binary.LittleEndian.AppendUint64(data, math.Float64bits(c.Dt))
+	data = binary.LittleEndian.AppendUint32(data, uint32(c.Target))
+	data = binary.LittleEndian.AppendUint64(data, c.Seed)
+	data = append(data, boolByte(c.PowerUps))
+	data = binary.LittleEndian.AppendUint64(data, r.Checksum)
+	data = binary.LittleEndian.AppendUint32(data, uint32(r.Score[sim.Left]))
+	data = binary.LittleEndian.AppendUint32(data, uint32(r.Score[sim.Right]))
+
+	// THE ARENA AS TEXT, EMPTY FOR AN OPEN COURT
+	arena := []byte{}
+	if c.Arena != nil {
+		arena, _ = c.Arena.MarshalText()
+	}
+
+	data = binary.AppendUvarint(data, uint64(len(arena)))
+	data = append(data, arena...)
+
+	for _, v := range []float64{c.BallSpeed, c.PaddleSize, c.ServeDelay} {
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+	}
+
+	data = binary.AppendUvarint(data, uint64(len(r.Inputs)))
+
+	// RUNS OF: BOTH INPUTS IN ONE BYTE, THEN HOW MANY STEPS THEY LASTED
+	for i := 0; i < len(r.Inputs); {
+
+		run := 1
+		for i+run < len(r.Inputs) && r.Inputs[i+run] == r.Inputs[i] {
+			run++
+		}
+
+		data = append(data, r.Inputs[i][sim.Left].Bits()|r.Inputs[i][sim.Right].Bits()<<4)
+		data = binary.AppendUvarint(data, uint64(run))
+
+		i += run
+	}
+
+	return data, nil
+}
+
+var errCorrupt = errors.New("replay: file is cut short or corrupt")
+
+// UnmarshalBinary decodes a replay written by MarshalBinary.
+func (r *Replay) UnmarshalBinary(data []byte) error {
+
+	if !bytes.HasPrefix(data, magic[:len(magic)-1]) {
+		return errors.New("replay: not a replay file")
+	}
+
+	if len(data) < len(magic) {
+		return errCorrupt
+	}
+
+	if version := data[len(magic)-1]; version != magic[len(magic)-1] {
+		return fmt.Errorf("replay: unknown format version %v", version)
+	}
+
+	data = data[len(magic):]
+
+	if len(data) < 37 {
+		return errCorrupt
+	}
+
+	*r = Replay{
+		Config: sim.Config{
+			Dt:       math.Float64frombits(binary.LittleEndian.Uint64(data)),
+			Target:   int(binary.LittleEndian.Uint32(data[8:])),
+			Seed:     binary.LittleEndian.Uint64(data[12:]),
+			PowerUps: data[20] != 0,
+		},
+		Checksum: binary.LittleEndian.Uint64(data[21:]),
+		Score:    [2]int{int(binary.LittleEndian.Uint32(data[29:])), int(binary.LittleEndian.Uint32(data[33:]))},
+	}
+
+	buf := bufio.NewReader(bytes.NewReader(data[37:]))
+
+	size, err := binary.ReadUvarint(buf)
+
+	if err != nil || size > uint64(len(data)) {
+		return errCorrupt
+	}
+
+	if size > 0 {
+		text := make([]byte, size)
+
+		if _, err := io.ReadFull(buf, text); err != nil {
+			return errCorrupt
+		}
+
+		arena, err := sim.ParseArena("replay's arena", text)
+
+		if err != nil {
+			return err
+		}
+
+		r.Config.Arena = arena
+	}
+
+	var values [3]uint64
+
+	if err := binary.Read(buf, binary.LittleEndian, &values); err != nil {
+		return errCorrupt
+	}
+
+	r.Config.BallSpeed = math.Float64frombits(values[0])
+	r.Config.PaddleSize = math.Float64frombits(values[1])
+	r.Config.ServeDelay = math.Float64frombits(values[2])
+
+	steps, err := binary.ReadUvarint(buf)
+
+	if err != nil {
+		return errCorrupt
+	}
+
+	r.Inputs = make([][2]sim.Input, 0, min(steps, 1<<20))
+
+	for uint64(len(r.Inputs)) < steps {
+
+		keys, err := buf.ReadByte()
+
+		if err != nil {
+			return errCorrupt
+		}
+
+		run, err := binary.ReadUvarint(buf)
+
+		if err != nil || run == 0 || uint64(len(r.Inputs))+run > steps {
+			return errCorrupt
+		}
+
+		inputs := [2]sim.Input{sim.InputFromBits(keys & 0xf), sim.InputFromBits(keys >> 4)}
+
+		for ; run > 0; run-- {
+			r.Inputs = append(r.Inputs, inputs)
+		}
+	}
+
+	if _, err := buf.ReadByte(); err != io.EOF {
+		return errCorrupt
+	}
+
+	return nil
+}
+
+// Load reads a replay file.
+func Load(path string) (*Replay, error) {
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Replay{}
+
+	if err := r.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+
+	return r, nil
+}
+
+// Save writes the replay to path.
+func (r *Replay) Save(path string) error {
+
+	data, err := r.MarshalBinary()
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+func boolByte(b bool) byte {
+
+	if b {
+		return 1
+	}
+
+	return 0
+}
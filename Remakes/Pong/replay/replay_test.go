@@ -0,0 +1,87 @@
+package replay_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pong/replay"
+)
+
+// TestRecorded plays every recorded match in testdata and fails if any of
+// them no longer ends the way it did, or no longer saves to the same bytes.
+// A change to the physics that's meant to be there needs the replays
+// recorded again with cmd/replay -record.
+func TestRecorded(t *testing.T) {
+
+	paths, err := filepath.Glob(filepath.Join("..", "testdata", "*"+replay.Ext))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) == 0 {
+		t.Fatal("no replays in testdata")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+
+			r, err := replay.Load(path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := r.Verify(); err != nil {
+				t.Fatal(err)
+			}
+
+			saved, err := os.ReadFile(path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := r.MarshalBinary()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(data, saved) {
+				t.Errorf("saves to %v bytes that differ from the %v in the file", len(data), len(saved))
+			}
+		})
+	}
+}
+
+func TestCorrupt(t *testing.T) {
+
+	saved, err := os.ReadFile(filepath.Join("..", "testdata", "classic"+replay.Ext))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newer := bytes.Clone(saved)
+	newer[7]++
+
+	tests := map[string][]byte{
+		"empty":        {},
+		"not a replay": []byte("hello, world"),
+		"cut short":    saved[:len(saved)/2],
+		"trailing":     append(bytes.Clone(saved), 0),
+		"newer":        newer,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			if err := (&replay.Replay{}).UnmarshalBinary(data); err == nil {
+				t.Error("decoded without an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"pong/replay"
+	"pong/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// maxReplays is how many replays are kept, the oldest go first.
+const maxReplays = 20
+
+// replayDir is where finished matches are saved, next to the stats.
+func replayDir() string {
+
+	return filepath.Join(filepath.Dir(statsPath()), "replays")
+}
+
+// saveReplay writes a finished match to the replay folder and clears out
+// old replays past maxReplays.
+func saveReplay(r *replay.Replay) {
+
+	if len(r.Inputs) == 0 {
+		return
+	}
+
+	dir := replayDir()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("replay: %v", err)
+		return
+	}
+
+	path := filepath.Join(dir, time.Now().Format("2006-01-02_15-04-05")+replay.Ext)
+
+	if err := r.Save(path); err != nil {
+		log.Printf("replay: %v", err)
+		return
+	}
+
+	// NAMES SORT BY DATE
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+replay.Ext))
+
+	if err != nil {
+		return
+	}
+
+	sort.Strings(paths)
+
+	for len(paths) > maxReplays {
+
+		if err := os.Remove(paths[0]); err != nil {
+			log.Printf("replay: %v", err)
+		}
+
+		paths = paths[1:]
+	}
+}
+
+// startReplay watches r. Inputs come from the replay instead of the
+// keyboard, and nothing is recorded.
+func (g *Game) startReplay(r *replay.Replay) {
+
+	g.world = sim.NewWorld(r.Config)
+	g.prev = g.world.Clone()
+	g.opponent = nil
+	g.playback = r
+	g.playStep = 0
+	g.recording = nil
+	g.recorded = true
+
+	g.clearFx()
+
+	// AT THE STEP IT WAS RECORDED AT, OR IT PLAYS FAST OR SLOW. THE NEXT
+	// GAME STARTED GOES BACK TO -step
+	g.clock = sim.Clock{Dt: g.world.Dt}
+	g.last = time.Now()
+
+	g.state = stateMatch
+}
+
+// nextInputs is what the paddles do this step: the keyboard and the
+// opponent, or the replay being watched. ok is false once a replay has run
+// out.
+func (g *Game) nextInputs(live [2]sim.Input) ([2]sim.Input, bool) {
+
+	if r := g.playback; r != nil {
+
+		if g.playStep >= len(r.Inputs) {
+			return [2]sim.Input{}, false
+		}
+
+		g.playStep++
+
+		return r.Inputs[g.playStep-1], true
+	}
+
+	if g.opponent != nil {
+		live[sim.Right] = g.opponent.Control(g.world, sim.Right)
+	}
+
+	g.recording = append(g.recording, live)
+
+	return live, true
+}
+
+func (g *Game) drawReplay(screen *ebiten.Image) {
+
+	r := g.playback
+
+	progStr := fmt.Sprintf("Replay %v / %v", g.playStep, len(r.Inputs))
+	text.Draw(screen, progStr, basicfont.Face7x13, 10, screenHeight-10, color.RGBA{150, 200, 200, 1})
+
+	if g.playStep < len(r.Inputs) {
+		return
+	}
+
+	// THE END SHOULD MATCH THE RECORDING EXACTLY
+	endStr := "Replay over, it played out as recorded"
+	if g.world.Checksum() != r.Checksum {
+		endStr = "Replay over, but it played out DIFFERENTLY than recorded"
+	}
+
+	text.Draw(screen, endStr, basicfont.Face7x13, (screenWidth-len(endStr)*7)/2, screenHeight/2+20, color.White)
+
+	if g.world.Phase != sim.MatchOver {
+		text.Draw(screen, "Esc - Menu", basicfont.Face7x13, screenWidth/2-35, screenHeight/2+60, color.RGBA{100, 200, 250, 1})
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"pong/sim"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	settingsFile    = "settings.json"
+	settingsVersion = 1
+
+	countdown = 3 // seconds counted down before every serve
+
+	// RANGES, IN PERCENT OF THE NORMAL SIZE OR SPEED
+	minBallSpeed  = 50
+	maxBallSpeed  = 150
+	minPaddleSize = 60
+	maxPaddleSize = 140
+	percentStep   = 10
+)
+
+// SETTINGS
+// What the player has set up, kept between runs next to the stats.
+type Settings struct {
+	Version    int              `json:"version"`
+	BallSpeed  int              `json:"ball_speed"`  // percent of sim.BallSpeed
+	PaddleSize int              `json:"paddle_size"` // percent of sim.PaddleHeight
+	Target     int              `json:"target"`      // points to win a match
+	Effects    bool             `json:"effects"`     // trails, particles, shake and the net
+	Keys       [2][2]ebiten.Key `json:"keys"`        // up and down of the left and right paddles
+	path       string
+}
+
+func defaultSettings(path string) *Settings {
+
+	return &Settings{
+		Version:    settingsVersion,
+		BallSpeed:  100,
+		PaddleSize: 100,
+		Target:     sim.DefaultTarget,
+		Effects:    true,
+		Keys: [2][2]ebiten.Key{
+			{ebiten.KeyW, ebiten.KeyS},
+			{ebiten.KeyArrowUp, ebiten.KeyArrowDown},
+		},
+		path: path,
+	}
+}
+
+// settingsPath is where the settings live, beside the stats.
+func settingsPath() string {
+
+	return filepath.Join(filepath.Dir(statsPath()), settingsFile)
+}
+
+// LoadSettings reads the settings at path. Like the stats, a missing file
+// means the defaults and a broken one is moved aside to path.bad.
+func LoadSettings(path string) *Settings {
+
+	s := defaultSettings(path)
+
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return s
+	}
+
+	if err == nil {
+		err = json.Unmarshal(data, s)
+	}
+
+	if err == nil && s.Version != settingsVersion {
+		err = fmt.Errorf("unknown version %v", s.Version)
+	}
+
+	if err != nil {
+		log.Printf("settings: %v is unreadable (%v), using the defaults", path, err)
+
+		if renameErr := os.Rename(path, path+".bad"); renameErr != nil {
+			log.Printf("settings: %v", renameErr)
+		}
+
+		return defaultSettings(path)
+	}
+
+	// A HAND EDIT MAY HAVE PUT ANYTHING IN
+	s.BallSpeed = min(max(s.BallSpeed, minBallSpeed), maxBallSpeed)
+	s.PaddleSize = min(max(s.PaddleSize, minPaddleSize), maxPaddleSize)
+	s.Target = min(max(s.Target, 1), maxTarget)
+
+	return s
+}
+
+// Save writes the settings the same safe way the stats are written.
+func (s *Settings) Save() error {
+
+	return saveJSON(s.path, s)
+}
+
+// save is Save for when there's nobody to tell it failed but the log.
+func (s *Settings) save() {
+
+	if err := s.Save(); err != nil {
+		log.Printf("settings: %v", err)
+	}
+}
+
+// matchConfig is a Pong match set up the way the settings say.
+func (s *Settings) matchConfig() sim.Config {
+
+	return sim.Config{
+		Target:     s.Target,
+		BallSpeed:  sim.BallSpeed * float64(s.BallSpeed) / 100,
+		PaddleSize: sim.PaddleHeight * float64(s.PaddleSize) / 100,
+		ServeDelay: countdown,
+	}
+}
+
+// SETTINGS SCREEN
+// Up and Down pick a line, Left and Right change it and Enter rebinds a
+// key. Esc goes back to wherever the screen was opened from.
+const (
+	itemBallSpeed = iota
+	itemPaddleSize
+	itemTarget
+	itemEffects
+	itemKeys     // the four keys follow, left up, left down, right up, right down
+	itemDefaults = itemKeys + 4
+	settingItems = itemDefaults + 1
+)
+
+// openSettings shows the settings screen, returning to the current state.
+func (g *Game) openSettings() {
+
+	g.settingsBack = g.state
+	g.settingsItem = 0
+	g.rebinding = false
+	g.state = stateSettings
+}
+
+func (g *Game) updateSettings() {
+
+	s := g.settings
+
+	// WAITING FOR THE NEW KEY, ESC LEAVES THE OLD ONE
+	if g.rebinding {
+
+		keys := inpututil.AppendJustPressedKeys(nil)
+
+		if len(keys) == 0 {
+			return
+		}
+
+		if keys[0] != ebiten.KeyEscape {
+			n := g.settingsItem - itemKeys
+			s.Keys[n/2][n%2] = keys[0]
+			s.save()
+		}
+
+		g.rebinding = false
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.state = g.settingsBack
+		return
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		g.settingsItem = (g.settingsItem + settingItems - 1) % settingItems
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		g.settingsItem = (g.settingsItem + 1) % settingItems
+	}
+
+	change := 0
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+		change = -1
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) {
+		change = 1
+	}
+
+	enter := inpututil.IsKeyJustPressed(ebiten.KeyEnter)
+
+	switch item := g.settingsItem; {
+
+	case item == itemBallSpeed && change != 0:
+		s.BallSpeed = min(max(s.BallSpeed+change*percentStep, minBallSpeed), maxBallSpeed)
+
+	case item == itemPaddleSize && change != 0:
+		s.PaddleSize = min(max(s.PaddleSize+change*percentStep, minPaddleSize), maxPaddleSize)
+
+	case item == itemTarget && change != 0:
+		s.Target = min(max(s.Target+change, 1), maxTarget)
+
+	case item == itemEffects && (change != 0 || enter):
+		s.Effects = !s.Effects
+
+	case item >= itemKeys && item < itemDefaults && enter:
+		g.rebinding = true
+		return
+
+	case item == itemDefaults && enter:
+		*s = *defaultSettings(s.path)
+
+	default:
+		return
+	}
+
+	s.save()
+}
+
+func (g *Game) drawSettings(screen *ebiten.Image) {
+
+	s := g.settings
+
+	text.Draw(screen, "SETTINGS", basicfont.Face7x13, screenWidth/2-28, 80, color.White)
+
+	onOff := "Off"
+	if s.Effects {
+		onOff = "On"
+	}
+
+	lines := []string{
+		fmt.Sprintf("Ball Speed      < %v%% >", s.BallSpeed),
+		fmt.Sprintf("Paddle Size     < %v%% >", s.PaddleSize),
+		fmt.Sprintf("Points to Win   < %v >", s.Target),
+		fmt.Sprintf("Effects         < %v >", onOff),
+	}
+
+	for i, name := range []string{"Left Up", "Left Down", "Right Up", "Right Down"} {
+
+		key := s.Keys[i/2][i%2].String()
+
+		if g.rebinding && g.settingsItem == itemKeys+i {
+			key = "press a key..."
+		}
+
+		lines = append(lines, fmt.Sprintf("%-15v %v", name, key))
+	}
+
+	lines = append(lines, "Restore Defaults")
+
+	for i, line := range lines {
+
+		clr := color.RGBA{150, 200, 200, 1}
+		prefix := "  "
+
+		if i == g.settingsItem {
+			clr = color.RGBA{255, 255, 255, 255}
+			prefix = "> "
+		}
+
+		text.Draw(screen, prefix+line, basicfont.Face7x13, screenWidth/2-120, 130+i*20, clr)
+	}
+
+	text.Draw(screen, "Ball speed, paddle size and points apply from the next match", basicfont.Face7x13, screenWidth/2-213, 350, color.RGBA{100, 200, 250, 1})
+	text.Draw(screen, "Up/Down - Choose    Left/Right - Change    Enter - Rebind Key", basicfont.Face7x13, screenWidth/2-213, 400, color.RGBA{100, 200, 250, 1})
+	text.Draw(screen, "Esc - Back", basicfont.Face7x13, screenWidth/2-35, 430, color.RGBA{100, 200, 250, 1})
+}
@@ -0,0 +1,302 @@
+package sim
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ARENA LAYOUT
+const (
+	ArenaMargin    = 60   // obstacles keep this far from the left and right edges, clear of the paddles
+	MinObstacle    = 10   // smallest width or height of an obstacle
+	bumperBoost    = 1.15 // speed gained off a bumper
+	maxObstacles   = 64
+	minMovePeriod  = 0.5 // fastest a moving obstacle may go there and back, in seconds
+	arenaNameLimit = 40
+)
+
+// OBSTACLE KINDS
+type ObstacleKind int
+
+const (
+	Wall   ObstacleKind = iota // the ball bounces off it
+	Bumper                     // the ball bounces off it faster than it came
+	Portal                     // the ball comes out of the paired portal, going the same way
+	obstacleKinds
+)
+
+func (k ObstacleKind) String() string {
+
+	switch k {
+	case Wall:
+		return "wall"
+	case Bumper:
+		return "bumper"
+	case Portal:
+		return "portal"
+	}
+
+	return "unknown"
+}
+
+// OBSTACLE
+// A moving obstacle slides from Home to Home plus (MoveX, MoveY) and back
+// again every Period seconds. Its Object is where it is right now.
+type Obstacle struct {
+	Object
+	Kind         ObstacleKind
+	Home         Object
+	MoveX, MoveY float64
+	Period       float64
+	Pair         int // index of the other portal of a pair, -1 for anything else
+}
+
+// Moving reports whether the obstacle moves at all.
+func (o *Obstacle) Moving() bool {
+
+	return o.Period > 0 && (o.MoveX != 0 || o.MoveY != 0)
+}
+
+// at puts the obstacle where it is t seconds into a match. The motion eases
+// in and out at both ends, so nothing ever jumps.
+func (o *Obstacle) at(t float64) {
+
+	o.Object = o.Home
+
+	if !o.Moving() {
+		return
+	}
+
+	along := (1 - math.Cos(2*math.Pi*t/o.Period)) / 2
+
+	o.X += o.MoveX * along
+	o.Y += o.MoveY * along
+}
+
+// ARENA
+// An arena fills the court with obstacles. Arenas never change once
+// parsed, so worlds and their clones share them.
+type Arena struct {
+	Name      string
+	Obstacles []Obstacle
+}
+
+// ParseArena reads an arena from its text layout, one obstacle per line:
+//
+//	kind x y w h [move dx dy seconds]
+//
+// where kind is wall, bumper or portal. Portals pair up in the order they
+// come, each one sending the ball to the other. Lines starting with '#'
+// are comments and an optional "name:" line names the arena. Obstacles,
+// wherever they move to, must stay inside the court and clear of the
+// paddles.
+func ParseArena(name string, data []byte) (*Arena, error) {
+
+	arena := &Arena{Name: name}
+	lastPortal := -1
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for n := 1; scanner.Scan(); n++ {
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if title, ok := strings.CutPrefix(line, "name:"); ok {
+			arena.Name = strings.TrimSpace(title)
+			continue
+		}
+
+		o, err := parseObstacle(strings.Fields(line))
+
+		if err != nil {
+			return nil, fmt.Errorf("%v: line %v: %w", name, n, err)
+		}
+
+		if err := o.Check(); err != nil {
+			return nil, fmt.Errorf("%v: line %v: %w", name, n, err)
+		}
+
+		// PAIR EACH PORTAL WITH THE ONE BEFORE IT, IF THAT ONE IS FREE
+		if o.Kind == Portal {
+			if lastPortal < 0 {
+				lastPortal = len(arena.Obstacles)
+			} else {
+				o.Pair = lastPortal
+				arena.Obstacles[lastPortal].Pair = len(arena.Obstacles)
+				lastPortal = -1
+			}
+		}
+
+		arena.Obstacles = append(arena.Obstacles, o)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if lastPortal >= 0 {
+		return nil, fmt.Errorf("%v: a portal has no partner, portals come in pairs", name)
+	}
+
+	if len(arena.Obstacles) > maxObstacles {
+		return nil, fmt.Errorf("%v: %v obstacles, at most %v are allowed", name, len(arena.Obstacles), maxObstacles)
+	}
+
+	if len(arena.Name) > arenaNameLimit {
+		arena.Name = arena.Name[:arenaNameLimit]
+	}
+
+	return arena, nil
+}
+
+func parseObstacle(fields []string) (Obstacle, error) {
+
+	o := Obstacle{Pair: -1}
+
+	if len(fields) != 5 && len(fields) != 9 {
+		return o, fmt.Errorf("expected kind x y w h [move dx dy seconds], got %q", strings.Join(fields, " "))
+	}
+
+	kind := -1
+	for k := Wall; k < obstacleKinds; k++ {
+		if fields[0] == k.String() {
+			kind = int(k)
+		}
+	}
+
+	if kind < 0 {
+		return o, fmt.Errorf("unknown obstacle %q", fields[0])
+	}
+
+	o.Kind = ObstacleKind(kind)
+
+	numbers := []*float64{&o.Home.X, &o.Home.Y, &o.Home.W, &o.Home.H}
+
+	if len(fields) == 9 {
+
+		if fields[5] != "move" {
+			return o, fmt.Errorf("expected move, got %q", fields[5])
+		}
+
+		fields = append(fields[:5], fields[6:]...)
+		numbers = append(numbers, &o.MoveX, &o.MoveY, &o.Period)
+	}
+
+	for i, num := range numbers {
+
+		v, err := strconv.ParseFloat(fields[i+1], 64)
+
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return o, fmt.Errorf("%q is not a number", fields[i+1])
+		}
+
+		*num = v
+	}
+
+	o.Object = o.Home
+
+	return o, nil
+}
+
+// Check makes sure the obstacle fits in the court all along its path and
+// stays clear of the serve.
+func (o *Obstacle) Check() error {
+
+	if o.Home.W < MinObstacle || o.Home.H < MinObstacle {
+		return fmt.Errorf("%v is smaller than %v by %v", o.Kind, MinObstacle, MinObstacle)
+	}
+
+	if (o.MoveX != 0 || o.MoveY != 0) && o.Period < minMovePeriod {
+		return fmt.Errorf("%v moves too fast, a period of at least %v seconds is needed", o.Kind, minMovePeriod)
+	}
+
+	for _, end := range []Object{o.Home, {X: o.Home.X + o.MoveX, Y: o.Home.Y + o.MoveY, W: o.Home.W, H: o.Home.H}} {
+
+		if end.X < ArenaMargin || end.X+end.W > Width-ArenaMargin || end.Y < 0 || end.Y+end.H > Height {
+			return fmt.Errorf("%v leaves the court or gets in the way of a paddle", o.Kind)
+		}
+	}
+
+	// EVERYWHERE IT PASSES, FROM ONE END TO THE OTHER
+	path := Object{
+		X: math.Min(o.Home.X, o.Home.X+o.MoveX),
+		Y: math.Min(o.Home.Y, o.Home.Y+o.MoveY),
+		W: o.Home.W + math.Abs(o.MoveX),
+		H: o.Home.H + math.Abs(o.MoveY),
+	}
+
+	if _, _, ok := Overlap(newBall().Object, path); ok {
+		return fmt.Errorf("%v gets in the way of the serve in the middle of the court", o.Kind)
+	}
+
+	return nil
+}
+
+// MarshalText writes the arena in the layout ParseArena reads.
+func (a *Arena) MarshalText() ([]byte, error) {
+
+	buf := bytes.Buffer{}
+
+	fmt.Fprintf(&buf, "name: %v\n", a.Name)
+
+	for _, o := range a.Obstacles {
+
+		fmt.Fprintf(&buf, "%v %v %v %v %v", o.Kind, o.Home.X, o.Home.Y, o.Home.W, o.Home.H)
+
+		if o.Moving() {
+			fmt.Fprintf(&buf, " move %v %v %v", o.MoveX, o.MoveY, o.Period)
+		}
+
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// placeObstacles moves every obstacle to where it is at this tick.
+func (w *World) placeObstacles() {
+
+	t := float64(w.Tick) * w.Dt
+
+	for i := range w.Obstacles {
+		w.Obstacles[i].at(t)
+	}
+}
+
+// hitObstacle handles the ball touching obstacle o with contact c.
+func (w *World) hitObstacle(b *Ball, o *Obstacle, c Contact) {
+
+	switch o.Kind {
+
+	case Wall:
+		reflect(b, c)
+
+	case Bumper:
+		reflect(b, c)
+
+		speed := math.Min(b.Speed*bumperBoost, MaxBallSpeed)
+		b.VX *= speed / b.Speed
+		b.VY *= speed / b.Speed
+		b.Speed = speed
+
+	case Portal:
+		// OUT OF THE MIDDLE OF THE OTHER PORTAL. A BALL OVERLAPPING A BOX
+		// NEVER SWEEPS INTO IT, SO IT CAN'T GO STRAIGHT BACK
+		exit := w.Obstacles[o.Pair]
+		b.X = exit.X + (exit.W-b.W)/2
+		b.Y = exit.Y + (exit.H-b.H)/2
+
+		// A PORTAL SMALLER THAN THE BALL, AGAINST A WALL, WOULD PUT IT
+		// PARTLY OUTSIDE. IT STILL COVERS THE PORTAL'S EDGE ONCE KEPT IN
+		b.X = math.Max(0, math.Min(b.X, Width-b.W))
+		b.Y = math.Max(0, math.Min(b.Y, Height-b.H))
+	}
+}
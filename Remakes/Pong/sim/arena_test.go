@@ -0,0 +1,87 @@
+package sim
+
+import "testing"
+
+func TestPortalExitStaysInCourt(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		layout string
+	}{
+		{"against the top", "portal 100 0 10 10\nportal 500 200 40 40"},
+		{"against the bottom", "portal 100 470 10 10\nportal 500 200 40 40"},
+		{"in a top corner", "portal 60 0 10 10\nportal 500 200 40 40"},
+		{"big enough", "portal 100 0 40 40\nportal 500 200 40 40"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			arena, err := ParseArena(test.name, []byte(test.layout))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := NewWorld(Config{Dt: DefaultStep, Arena: arena})
+			exit := w.Obstacles[0]
+
+			// IN THROUGH THE BIG PORTAL, OUT OF THE SMALL ONE
+			b := &w.Balls[0]
+			b.VX, b.VY = -BallSpeed, -BallSpeed
+			w.hitObstacle(b, &w.Obstacles[1], Contact{NX: 1})
+
+			if b.X < 0 || b.X+b.W > Width || b.Y < 0 || b.Y+b.H > Height {
+				t.Errorf("ball left at %v, %v, outside the court", b.X, b.Y)
+			}
+
+			// STILL OVER THE EXIT, SO IT CAN'T BE SENT STRAIGHT BACK
+			if _, _, ok := Overlap(b.Object, exit.Object); !ok {
+				t.Errorf("ball left at %v, %v, off the exit portal at %v, %v", b.X, b.Y, exit.X, exit.Y)
+			}
+		})
+	}
+}
+
+// TestSqueezedBallStaysInCourt steps a ball caught between a wall and a
+// moving obstacle closing on it, which must never push it out of the court.
+func TestSqueezedBallStaysInCourt(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		layout string
+		x, y   float64
+		vx, vy float64
+	}{
+		{"against the top", "wall 200 30 100 20 move 0 -30 1", 205, 12, 120, -160},
+		{"against the bottom", "wall 200 430 100 20 move 0 30 1", 205, 458, 120, 160},
+		{"going the other way", "wall 200 30 100 20 move 0 -30 1", 205, 12, -120, 160},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			arena, err := ParseArena(test.name, []byte(test.layout))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w := NewWorld(Config{Dt: DefaultStep, Arena: arena})
+			w.Phase = Playing
+
+			b := &w.Balls[0]
+			b.X, b.Y = test.x, test.y
+			b.VX, b.VY = test.vx, test.vy
+
+			for step := 1; step <= 120 && w.Phase == Playing; step++ {
+
+				w.Step([2]Input{})
+
+				if b := w.Balls[0]; b.Y < 0 || b.Y+b.H > Height {
+					t.Fatalf("step %v: ball at %v, %v, outside the court", step, b.X, b.Y)
+				}
+			}
+		})
+	}
+}
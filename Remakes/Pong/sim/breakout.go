@@ -0,0 +1,253 @@
+package sim
+
+import "math"
+
+// BREAKOUT
+const (
+	breakoutLives      = 3
+	breakoutPaddleW    = 100
+	breakoutPaddleH    = 16
+	breakoutPaddleY    = Height - 40
+	breakoutBallSpeed  = 300
+	levelClearDelay    = 1.5 // seconds the cleared board stays up before the next level
+	pointsPerBrickHit  = 10
+	pointsPerBrickKill = 50
+)
+
+// the left, right and top walls of the Breakout court
+var (
+	leftWall  = Object{X: -Width, Y: -Height, W: Width, H: 3 * Height}
+	rightWall = Object{X: Width, Y: -Height, W: Width, H: 3 * Height}
+)
+
+// BREAKOUT PHASES
+type BreakoutPhase int
+
+const (
+	BreakoutServing BreakoutPhase = iota
+	BreakoutPlaying
+	LevelCleared
+	GameOver
+	GameWon
+)
+
+// Breakout is the brick breaking mode: one horizontal paddle at the bottom
+// of the court, a ball and a wall of bricks to clear, level after level.
+type Breakout struct {
+	Dt         float64
+	Paddle     Paddle
+	Ball       Ball
+	Bricks     []Brick
+	Levels     []Level
+	LevelIndex int
+	Lives      int
+	Score      int
+	Phase      BreakoutPhase
+	Timer      float64
+	Tick       int // steps since the game started
+	rng        rng
+}
+
+// NewBreakout starts a game on the first of levels, which must not be empty.
+func NewBreakout(levels []Level, dt float64, seed uint64) *Breakout {
+
+	if dt <= 0 {
+		dt = DefaultStep
+	}
+
+	b := &Breakout{
+		Dt: dt,
+		Paddle: Paddle{
+			Object:     Object{X: (Width - breakoutPaddleW) / 2, Y: breakoutPaddleY, W: breakoutPaddleW, H: breakoutPaddleH},
+			Side:       NoSide,
+			Horizontal: true,
+		},
+		Levels: levels,
+		rng:    newRNG(seed),
+	}
+
+	b.Restart()
+
+	return b
+}
+
+// Restart goes back to the first level with full lives.
+func (b *Breakout) Restart() {
+
+	b.Lives = breakoutLives
+	b.Score = 0
+	b.Tick = 0
+
+	b.loadLevel(0)
+}
+
+func (b *Breakout) loadLevel(index int) {
+
+	b.LevelIndex = index
+	b.Bricks = append([]Brick(nil), b.Levels[index].Bricks...)
+
+	b.serve()
+}
+
+// serve rests a fresh ball on the paddle for ServeDelay seconds.
+func (b *Breakout) serve() {
+
+	b.Ball = Ball{
+		Object: Object{W: BallSize, H: BallSize},
+		Speed:  breakoutBallSpeed,
+		Owner:  NoSide,
+	}
+
+	b.restBall()
+
+	b.Timer = ServeDelay
+	b.Phase = BreakoutServing
+}
+
+func (b *Breakout) restBall() {
+
+	b.Ball.X = b.Paddle.X + (b.Paddle.W-b.Ball.W)/2
+	b.Ball.Y = b.Paddle.Y - b.Ball.H
+}
+
+// Step advances the game by one fixed step.
+func (b *Breakout) Step(in Input) {
+
+	b.Tick++
+
+	switch b.Phase {
+
+	case BreakoutServing:
+		b.Paddle.Control(in, b.Dt)
+		b.restBall()
+
+		b.Timer -= b.Dt
+		if b.Timer <= 0 {
+			// STRAIGHT UP, GIVE OR TAKE 30 DEGREES
+			angle := (b.rng.float()*2 - 1) * serveAngle
+			b.Ball.VX = b.Ball.Speed * math.Sin(angle)
+			b.Ball.VY = -b.Ball.Speed * math.Cos(angle)
+			b.Phase = BreakoutPlaying
+		}
+
+	case BreakoutPlaying:
+		b.Paddle.Control(in, b.Dt)
+		b.moveBall()
+
+		if b.Ball.Y >= Height {
+			b.loseLife()
+		} else if len(b.Bricks) == 0 {
+			b.Timer = levelClearDelay
+			b.Phase = LevelCleared
+		}
+
+	case LevelCleared:
+		b.Timer -= b.Dt
+
+		if b.Timer > 0 {
+			break
+		}
+
+		if b.LevelIndex+1 < len(b.Levels) {
+			b.loadLevel(b.LevelIndex + 1)
+		} else {
+			b.Phase = GameWon
+		}
+	}
+}
+
+func (b *Breakout) loseLife() {
+
+	b.Lives--
+
+	if b.Lives <= 0 {
+		b.Phase = GameOver
+		return
+	}
+
+	b.serve()
+}
+
+// moveBall sweeps the ball through the walls, the paddle and the bricks.
+// The bottom of the court is open.
+func (b *Breakout) moveBall() {
+
+	ball := &b.Ball
+	p := &b.Paddle
+
+	if c, depth, ok := Overlap(ball.Object, p.Object); ok {
+		ball.move(c.NX*depth, c.NY*depth)
+		ball.bounceOff(p, c)
+	}
+
+	solids := []Object{leftWall, rightWall, topWall, p.Object}
+
+	for _, brick := range b.Bricks {
+		solids = append(solids, brick.Object)
+	}
+
+	// indexes into solids shift as bricks break, so remember which are gone
+	broken := make([]bool, len(b.Bricks))
+
+	hit := func(i int, c Contact) bool {
+
+		switch {
+
+		case i < 3:
+			reflect(ball, c)
+
+		case i == 3:
+			ball.bounceOff(p, c)
+
+		default:
+			reflect(ball, c)
+
+			brick := &b.Bricks[i-4]
+			brick.HP--
+			b.Score += pointsPerBrickHit
+
+			if brick.HP <= 0 {
+				b.Score += pointsPerBrickKill
+				broken[i-4] = true
+
+				// MOVE IT OUT OF THE WAY FOR THE REST OF THIS STEP
+				solids[i] = Object{X: -Width, Y: -Height}
+			}
+		}
+
+		return true
+	}
+
+	travel(ball, b.Dt, solids, hit, nil)
+
+	bricks := b.Bricks[:0]
+
+	for i, brick := range b.Bricks {
+		if !broken[i] {
+			bricks = append(bricks, brick)
+		}
+	}
+
+	b.Bricks = bricks
+}
+
+// reflect bounces the ball off a still surface with normal c.
+func reflect(b *Ball, c Contact) {
+
+	if c.NX != 0 {
+		b.VX = c.NX * math.Abs(b.VX)
+	}
+
+	if c.NY != 0 {
+		b.VY = c.NY * math.Abs(b.VY)
+	}
+}
+
+// Clone returns a deep copy of the game that shares nothing with b.
+func (b *Breakout) Clone() *Breakout {
+
+	c := *b
+	c.Bricks = append([]Brick(nil), b.Bricks...)
+
+	return &c
+}
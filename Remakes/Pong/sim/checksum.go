@@ -0,0 +1,113 @@
+package sim
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+)
+
+// Checksum hashes everything that decides how the world plays out from
+// here on. Two worlds that have been given the same inputs since the same
+// Config have the same checksum, so peers can compare them to catch a
+// desync without sending the whole state.
+func (w *World) Checksum() uint64 {
+
+	s := summer{h: fnv.New64a()}
+
+	s.float(w.Dt)
+	s.int(w.Target)
+	s.bool(w.PowerUps)
+
+	for _, p := range w.Paddles {
+		s.object(p.Object)
+		s.float(p.VX)
+		s.float(p.VY)
+	}
+
+	s.int(len(w.Balls))
+	for _, b := range w.Balls {
+		s.object(b.Object)
+		s.float(b.VX)
+		s.float(b.VY)
+		s.float(b.Speed)
+		s.int(b.Hits)
+		s.int(int(b.Owner))
+		s.bool(b.Stuck)
+		s.float(b.StuckY)
+		s.float(b.StuckTimer)
+	}
+
+	s.int(len(w.Pickups))
+	for _, p := range w.Pickups {
+		s.object(p.Object)
+		s.int(int(p.Kind))
+		s.float(p.Life)
+	}
+
+	s.int(len(w.Effects))
+	for _, e := range w.Effects {
+		s.int(int(e.Kind))
+		s.int(int(e.Target))
+		s.float(e.Remaining)
+	}
+
+	s.int(len(w.Obstacles))
+	for _, o := range w.Obstacles {
+		s.object(o.Object)
+		s.int(int(o.Kind))
+	}
+
+	s.int(w.Score[Left])
+	s.int(w.Score[Right])
+	s.int(w.Rally)
+	s.int(w.LongestRally)
+	s.int(int(w.Phase))
+	s.float(w.ServeTimer)
+	s.float(w.ServeDir)
+	s.int(int(w.Winner))
+	s.int(w.Tick)
+	s.float(w.SpawnTimer)
+	s.uint(w.rng.state)
+
+	return s.h.Sum64()
+}
+
+// summer feeds values into a hash in a fixed byte order.
+type summer struct {
+	h   hash.Hash64
+	buf [8]byte
+}
+
+func (s *summer) uint(v uint64) {
+
+	binary.LittleEndian.PutUint64(s.buf[:], v)
+	s.h.Write(s.buf[:])
+}
+
+func (s *summer) int(v int) {
+
+	s.uint(uint64(v))
+}
+
+func (s *summer) float(v float64) {
+
+	s.uint(math.Float64bits(v))
+}
+
+func (s *summer) bool(v bool) {
+
+	if v {
+		s.uint(1)
+	} else {
+		s.uint(0)
+	}
+}
+
+func (s *summer) object(o Object) {
+
+	s.float(o.X)
+	s.float(o.Y)
+	s.float(o.W)
+	s.float(o.H)
+}
@@ -0,0 +1,54 @@
+package sim
+
+// maxFrame caps how much real time a single Advance may catch up on, so a
+// long stall (window dragged, breakpoint hit) doesn't fast forward the game.
+const maxFrame = 0.25
+
+// CLOCK
+// Clock turns real elapsed time into a whole number of fixed steps, keeping
+// the leftover in an accumulator for the next frame.
+type Clock struct {
+	Dt  float64
+	acc float64
+}
+
+// Advance adds elapsed seconds to the accumulator and returns how many
+// steps should be simulated now, none for a clock without a step.
+func (c *Clock) Advance(elapsed float64) int {
+
+	if c.Dt <= 0 {
+		return 0
+	}
+
+	if elapsed > maxFrame {
+		elapsed = maxFrame
+	}
+
+	c.acc += elapsed
+
+	steps := 0
+
+	for c.acc >= c.Dt {
+		c.acc -= c.Dt
+		steps++
+	}
+
+	return steps
+}
+
+// Alpha is how far, from 0 to 1, real time has got between the last
+// simulated step and the next one. Draw uses it to interpolate positions.
+func (c *Clock) Alpha() float64 {
+
+	if c.Dt <= 0 {
+		return 0
+	}
+
+	return c.acc / c.Dt
+}
+
+// Reset drops any accumulated time.
+func (c *Clock) Reset() {
+
+	c.acc = 0
+}
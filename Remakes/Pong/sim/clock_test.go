@@ -0,0 +1,40 @@
+package sim
+
+import "testing"
+
+func TestClock(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		dt      float64
+		elapsed []float64
+		steps   int
+		alpha   float64
+	}{
+		{"one frame", 0.01, []float64{0.025}, 2, 0.5},
+		{"frames add up", 0.01, []float64{0.004, 0.004, 0.004}, 1, 0.2},
+		{"a stall is capped", 1.0 / 16, []float64{10}, 4, 0},
+		{"no step", 0, []float64{0.1}, 0, 0},
+		{"negative step", -0.01, []float64{0.1}, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			c := Clock{Dt: test.dt}
+			steps := 0
+
+			for _, e := range test.elapsed {
+				steps += c.Advance(e)
+			}
+
+			if steps != test.steps {
+				t.Errorf("%v steps, want %v", steps, test.steps)
+			}
+
+			if alpha := c.Alpha(); alpha < test.alpha-1e-6 || alpha > test.alpha+1e-6 {
+				t.Errorf("alpha %v, want %v", alpha, test.alpha)
+			}
+		})
+	}
+}
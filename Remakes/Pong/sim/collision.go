@@ -0,0 +1,262 @@
+package sim
+
+import "math"
+
+const (
+	// most surfaces the ball may touch in a single step, enough for a
+	// corner between a wall and a paddle with some room to spare
+	maxContacts = 4
+
+	// how far back in time a contact may be and still count as touching,
+	// to soak up rounding after the ball was moved exactly onto a surface
+	touchSlack = 1e-9
+)
+
+// the top and bottom walls as solid boxes just outside the court
+var (
+	topWall    = Object{X: -Width, Y: -Height, W: 3 * Width, H: Height}
+	bottomWall = Object{X: -Width, Y: Height, W: 3 * Width, H: Height}
+)
+
+// CONTACT
+type Contact struct {
+	T      float64 // fraction of the movement travelled before touching
+	NX, NY float64 // surface normal at the point of impact
+}
+
+// Sweep moves box a by (dx, dy) against the static box b and reports the
+// first time of impact as a fraction of the movement. Only contacts where
+// a is heading into b are reported, so a box resting against b and moving
+// away is free to leave. Boxes that already overlap are left to Overlap.
+// When both axes are entered at the same moment (a perfect corner hit)
+// the horizontal face wins.
+func Sweep(a Object, dx, dy float64, b Object) (Contact, bool) {
+
+	xEntry, xExit := sweepAxis(a.X, a.W, dx, b.X, b.W)
+	yEntry, yExit := sweepAxis(a.Y, a.H, dy, b.Y, b.H)
+
+	entry := math.Max(xEntry, yEntry)
+	exit := math.Min(xExit, yExit)
+
+	if entry > exit || entry > 1 || exit <= 0 || entry < -touchSlack {
+		return Contact{}, false
+	}
+
+	contact := Contact{T: math.Max(entry, 0)}
+
+	if xEntry >= yEntry {
+		contact.NX = -sign(dx)
+	} else {
+		contact.NY = -sign(dy)
+	}
+
+	// moving away from the face it would touch
+	if contact.NX*dx+contact.NY*dy >= 0 {
+		return Contact{}, false
+	}
+
+	return contact, true
+}
+
+// Overlap reports whether box a is inside box b, and if so the shortest
+// way out: the normal of the nearest face of b and how far a has to move
+// along it.
+func Overlap(a, b Object) (Contact, float64, bool) {
+
+	exits := []struct {
+		depth  float64
+		nx, ny float64
+	}{
+		{a.X + a.W - b.X, -1, 0},
+		{b.X + b.W - a.X, 1, 0},
+		{a.Y + a.H - b.Y, 0, -1},
+		{b.Y + b.H - a.Y, 0, 1},
+	}
+
+	best := exits[0]
+
+	for _, e := range exits {
+
+		if e.depth <= 0 {
+			return Contact{}, 0, false
+		}
+
+		if e.depth < best.depth {
+			best = e
+		}
+	}
+
+	return Contact{NX: best.nx, NY: best.ny}, best.depth, true
+}
+
+// sweepAxis returns when, as a fraction of d, the segment [pos, pos+size]
+// starts and stops overlapping [other, other+otherSize].
+func sweepAxis(pos, size, d, other, otherSize float64) (float64, float64) {
+
+	if d == 0 {
+		if pos < other+otherSize && other < pos+size {
+			return math.Inf(-1), math.Inf(1)
+		}
+		return math.Inf(1), math.Inf(-1)
+	}
+
+	var near, far float64
+
+	if d > 0 {
+		near = other - (pos + size)
+		far = other + otherSize - pos
+	} else {
+		near = other + otherSize - pos
+		far = other - (pos + size)
+	}
+
+	return near / d, far / d
+}
+
+func sign(v float64) float64 {
+
+	if v > 0 {
+		return 1
+	}
+
+	if v < 0 {
+		return -1
+	}
+
+	return 0
+}
+
+// travel moves b for dt seconds through solids. Each time the ball
+// touches one it stops there and hit is asked to bounce it, then it carries
+// on with whatever is left of the movement, so a fast ball can never skip
+// past anything between two steps. hit returns false to leave the ball
+// where it is. moved, if not nil, sees each straight stretch just before
+// the ball covers it.
+func travel(b *Ball, dt float64, solids []Object, hit func(i int, c Contact) bool, moved func(dx, dy float64)) {
+
+	remaining := dt
+
+	for n := 0; n < maxContacts && remaining > 0; n++ {
+
+		dx := b.VX * remaining
+		dy := b.VY * remaining
+
+		first := Contact{T: math.Inf(1)}
+		index := -1
+
+		for i, solid := range solids {
+			if c, ok := Sweep(b.Object, dx, dy, solid); ok && c.T < first.T {
+				first = c
+				index = i
+			}
+		}
+
+		if index < 0 {
+			first.T = 1
+		}
+
+		if moved != nil {
+			moved(dx*first.T, dy*first.T)
+		}
+
+		b.move(dx*first.T, dy*first.T)
+
+		if index < 0 || !hit(index, first) {
+			return
+		}
+
+		remaining *= 1 - first.T
+	}
+}
+
+// moveBall sweeps a ball through this step's movement, bouncing off the
+// walls, paddles and obstacles and collecting any pickups along the way.
+func (w *World) moveBall(b *Ball) {
+
+	// STUCK BALLS RIDE ALONG WITH THEIR PADDLE INSTEAD
+	if b.Stuck {
+		return
+	}
+
+	// A PADDLE MAY HAVE MOVED INTO THE BALL, PUSH IT BACK OUT FIRST
+	for i := range w.Paddles {
+		p := &w.Paddles[i]
+
+		if c, depth, ok := Overlap(b.Object, p.Object); ok {
+			b.move(c.NX*depth, c.NY*depth)
+			w.bounce(b, p, c)
+		}
+	}
+
+	// AND SO MAY A MOVING OBSTACLE, OR THE BALL MAY HAVE BEEN SERVED INTO ONE
+	for i := range w.Obstacles {
+		o := &w.Obstacles[i]
+
+		if c, depth, ok := Overlap(b.Object, o.Object); ok && o.Kind != Portal {
+			b.move(c.NX*depth, c.NY*depth)
+			w.hitObstacle(b, o, c)
+			keepOffWalls(b)
+		}
+	}
+
+	solids := []Object{topWall, bottomWall}
+
+	for _, p := range w.Paddles {
+		solids = append(solids, p.Object)
+	}
+
+	firstObstacle := len(solids)
+
+	for _, o := range w.Obstacles {
+		solids = append(solids, o.Object)
+	}
+
+	hit := func(i int, c Contact) bool {
+
+		switch {
+		case i < 2:
+			w.bounce(b, nil, c)
+		case i < firstObstacle:
+			w.bounce(b, &w.Paddles[i-2], c)
+		default:
+			w.hitObstacle(b, &w.Obstacles[i-firstObstacle], c)
+		}
+
+		return !b.Stuck
+	}
+
+	moved := func(dx, dy float64) {
+		w.collectPickups(b, dx, dy)
+	}
+
+	travel(b, w.Dt, solids, hit, moved)
+}
+
+// keepOffWalls puts a ball pushed into the top or bottom wall back on the
+// court, going away from the wall. An obstacle closing on a wall can push
+// the ball further than any sweep would catch.
+func keepOffWalls(b *Ball) {
+
+	switch {
+	case b.Y < 0:
+		b.Y = 0
+		b.VY = math.Abs(b.VY)
+	case b.Y > Height-b.H:
+		b.Y = Height - b.H
+		b.VY = -math.Abs(b.VY)
+	}
+}
+
+// bounce turns the ball away from a surface with normal c. p is the paddle
+// that was hit, or nil for a wall.
+func (w *World) bounce(b *Ball, p *Paddle, c Contact) {
+
+	if p == nil {
+		b.VY = -b.VY
+		return
+	}
+
+	if b.bounceOff(p, c) {
+		w.hitPaddle(b, p)
+	}
+}
@@ -0,0 +1,246 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+func near(a, b float64) bool {
+
+	return math.Abs(a-b) < epsilon
+}
+
+func TestSweep(t *testing.T) {
+
+	ball := Object{X: 0, Y: 100, W: BallSize, H: BallSize}
+	paddle := Object{X: 300, Y: 50, W: PaddleWidth, H: PaddleHeight}
+
+	tests := []struct {
+		name   string
+		a      Object
+		dx, dy float64
+		b      Object
+		hit    bool
+		want   Contact
+	}{
+		{
+			name: "slow ball stops short",
+			a:    ball, dx: 100, dy: 0, b: paddle,
+		},
+		{
+			name: "slow ball reaches the face",
+			a:    ball, dx: 560, dy: 0, b: paddle,
+			hit: true, want: Contact{T: 280.0 / 560, NX: -1},
+		},
+
+		// A STEP LONGER THAN THE WHOLE COURT STILL STOPS AT THE FIRST FACE
+		{
+			name: "10k px/s for a step",
+			a:    ball, dx: 10000.0 / 60, dy: 0, b: Object{X: 100, Y: 0, W: PaddleWidth, H: Height},
+			hit: true, want: Contact{T: 80 / (10000.0 / 60), NX: -1},
+		},
+		{
+			name: "1M px/s for a step",
+			a:    ball, dx: 1e6 / 60, dy: 0, b: paddle,
+			hit: true, want: Contact{T: 280 / (1e6 / 60), NX: -1},
+		},
+		{
+			name: "1M px/s leftwards",
+			a:    Object{X: 600, Y: 100, W: BallSize, H: BallSize}, dx: -1e6 / 60, dy: 0, b: paddle,
+			hit: true, want: Contact{T: (600 - 300 - PaddleWidth) / (1e6 / 60), NX: 1},
+		},
+		{
+			name: "fast ball passing above",
+			a:    Object{X: 0, Y: 10, W: BallSize, H: BallSize}, dx: 1e6 / 60, dy: 0, b: paddle,
+		},
+		{
+			name: "fast ball moving away",
+			a:    Object{X: 400, Y: 100, W: BallSize, H: BallSize}, dx: 1e6 / 60, dy: 0, b: paddle,
+		},
+
+		// BOTH AXES ENTERED AT ONCE, THE HORIZONTAL FACE WINS
+		{
+			name: "exact corner",
+			a:    Object{X: 0, Y: 0, W: 10, H: 10}, dx: 20, dy: 20, b: Object{X: 20, Y: 20, W: 10, H: 10},
+			hit: true, want: Contact{T: 0.5, NX: -1},
+		},
+		{
+			name: "exact corner, up and left",
+			a:    Object{X: 40, Y: 40, W: 10, H: 10}, dx: -20, dy: -20, b: Object{X: 20, Y: 20, W: 10, H: 10},
+			hit: true, want: Contact{T: 0.5, NX: 1},
+		},
+		{
+			name: "exact corner at 10k px/s",
+			a:    Object{X: 0, Y: 0, W: 10, H: 10}, dx: 10000, dy: 10000, b: Object{X: 20, Y: 20, W: 10, H: 10},
+			hit: true, want: Contact{T: 0.001, NX: -1},
+		},
+		{
+			name: "just below the corner hits the side",
+			a:    Object{X: 0, Y: 1, W: 10, H: 10}, dx: 20, dy: 20, b: Object{X: 20, Y: 20, W: 10, H: 10},
+			hit: true, want: Contact{T: 0.5, NX: -1},
+		},
+		{
+			name: "just right of the corner hits the top",
+			a:    Object{X: 1, Y: 0, W: 10, H: 10}, dx: 20, dy: 20, b: Object{X: 20, Y: 20, W: 10, H: 10},
+			hit: true, want: Contact{T: 0.5, NY: -1},
+		},
+		{
+			name: "resting against the face and leaving",
+			a:    Object{X: 280, Y: 100, W: BallSize, H: BallSize}, dx: -50, dy: 0, b: paddle,
+		},
+		{
+			name: "resting against the face and pushing",
+			a:    Object{X: 280, Y: 100, W: BallSize, H: BallSize}, dx: 50, dy: 0, b: paddle,
+			hit: true, want: Contact{T: 0, NX: -1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			c, ok := Sweep(test.a, test.dx, test.dy, test.b)
+
+			if ok != test.hit {
+				t.Fatalf("hit = %v, want %v (contact %+v)", ok, test.hit, c)
+			}
+
+			if ok && (!near(c.T, test.want.T) || c.NX != test.want.NX || c.NY != test.want.NY) {
+				t.Errorf("contact = %+v, want %+v", c, test.want)
+			}
+		})
+	}
+}
+
+func TestTravelNoTunnelling(t *testing.T) {
+
+	wall := Object{X: 100, Y: 0, W: PaddleWidth, H: Height}
+
+	for _, speed := range []float64{BallSpeed, 10000, 50000, 1e6} {
+
+		b := Ball{Object: Object{X: 0, Y: 100, W: BallSize, H: BallSize}, VX: speed}
+		hits := 0
+
+		travel(&b, 1.0/60, []Object{wall}, func(i int, c Contact) bool {
+			hits++
+			b.VX = -b.VX
+			return true
+		}, nil)
+
+		if b.X+b.W > wall.X+epsilon {
+			t.Errorf("at %v px/s the ball ended at x %v, through the wall at %v", speed, b.X, wall.X)
+		}
+
+		if reaches := speed/60 >= wall.X-BallSize; reaches != (hits == 1) {
+			t.Errorf("at %v px/s the ball hit the wall %v times", speed, hits)
+		}
+	}
+}
+
+func TestTravel(t *testing.T) {
+
+	// A NARROW GAP THE BALL CAN ONLY RATTLE UP AND DOWN IN
+	ceiling := Object{X: -100, Y: -100, W: 300, H: 100}
+	floor := Object{X: -100, Y: BallSize + 10, W: 300, H: 100}
+
+	tests := []struct {
+		name   string
+		ball   Ball
+		dt     float64
+		solids []Object
+		stop   int // hits that leave the ball where it is, 0 for none
+
+		hits     []int
+		x, y     float64
+		vx, vy   float64
+		checkEnd bool
+	}{
+		{
+			name: "paddle then wall in one step",
+			ball: Ball{Object: Object{X: 100, Y: 100, W: BallSize, H: BallSize}, VX: -6000, VY: -12000},
+			dt:   1.0 / 60,
+			solids: []Object{
+				topWall,
+				{X: 60, Y: 0, W: 10, H: Height},
+			},
+			hits: []int{1, 0},
+			x:    140, y: 100, vx: 6000, vy: 12000, checkEnd: true,
+		},
+		{
+			name: "no more than maxContacts",
+			ball: Ball{Object: Object{X: 0, Y: 5, W: BallSize, H: BallSize}, VY: 1e6},
+			dt:   1,
+			solids: []Object{
+				ceiling,
+				floor,
+			},
+			hits: []int{1, 0, 1, 0},
+		},
+		{
+			name: "a hit can leave the ball where it is",
+			ball: Ball{Object: Object{X: 0, Y: 5, W: BallSize, H: BallSize}, VY: 1e6},
+			dt:   1,
+			solids: []Object{
+				ceiling,
+				floor,
+			},
+			stop: 1,
+			hits: []int{1},
+			x:    0, y: 10, vx: 0, vy: -1e6, checkEnd: true,
+		},
+		{
+			name:   "nothing in the way",
+			ball:   Ball{Object: Object{X: 100, Y: 100, W: BallSize, H: BallSize}, VX: 600, VY: 100},
+			dt:     0.5,
+			solids: []Object{topWall, bottomWall},
+			x:      400, y: 150, vx: 600, vy: 100, checkEnd: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			b := test.ball
+			hits := []int{}
+
+			travel(&b, test.dt, test.solids, func(i int, c Contact) bool {
+
+				hits = append(hits, i)
+
+				if c.NX != 0 {
+					b.VX = -b.VX
+				} else {
+					b.VY = -b.VY
+				}
+
+				return len(hits) != test.stop
+			}, nil)
+
+			if len(hits) > maxContacts {
+				t.Fatalf("%v contacts in one step, more than maxContacts (%v)", len(hits), maxContacts)
+			}
+
+			if len(hits) != len(test.hits) {
+				t.Fatalf("hit %v, want %v", hits, test.hits)
+			}
+
+			for i := range hits {
+				if hits[i] != test.hits[i] {
+					t.Fatalf("hit %v, want %v", hits, test.hits)
+				}
+			}
+
+			// NOTHING EVER ENDS UP INSIDE A SOLID
+			for i, solid := range test.solids {
+				if _, depth, ok := Overlap(b.Object, solid); ok && depth > epsilon {
+					t.Errorf("the ball ended %v inside solid %v", depth, i)
+				}
+			}
+
+			if test.checkEnd && (!near(b.X, test.x) || !near(b.Y, test.y) || b.VX != test.vx || b.VY != test.vy) {
+				t.Errorf("ball at %v, %v moving %v, %v, want %v, %v moving %v, %v", b.X, b.Y, b.VX, b.VY, test.x, test.y, test.vx, test.vy)
+			}
+		})
+	}
+}
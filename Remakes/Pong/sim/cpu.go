@@ -0,0 +1,205 @@
+package sim
+
+import "math"
+
+// CONTROLLER
+// A Controller plays one paddle by choosing its input every step. The CPU
+// is one, and so is anything else that wants to take a human's place.
+type Controller interface {
+	Control(w *World, side Side) Input
+}
+
+// DIFFICULTY LEVELS
+type Difficulty int
+
+const (
+	Easy Difficulty = iota
+	Normal
+	Hard
+)
+
+func (d Difficulty) String() string {
+
+	switch d {
+	case Easy:
+		return "Easy"
+	case Hard:
+		return "Hard"
+	}
+
+	return "Normal"
+}
+
+// how the CPU behaves at each level:
+// reactionDelay - seconds between two looks at the ball
+// maxSpeed      - fastest the paddle is allowed to move, in pixels per second
+// predictError  - max pixels the predicted landing spot can be off by
+type cpuSettings struct {
+	reactionDelay float64
+	maxSpeed      float64
+	predictError  float64
+}
+
+var difficulties = map[Difficulty]cpuSettings{
+	Easy:   {reactionDelay: 0.3, maxSpeed: 180, predictError: 80},
+	Normal: {reactionDelay: 0.15, maxSpeed: 270, predictError: 55},
+	Hard:   {reactionDelay: 0.05, maxSpeed: PaddleSpeed, predictError: 25},
+}
+
+// CPU CONTROLLER
+// The CPU plays through the same Input a human does. A paddle can only
+// move at PaddleSpeed or not at all, so slower levels hold the key down
+// for just a share of the steps.
+type CPU struct {
+	Level    Difficulty
+	settings cpuSettings
+	timer    float64
+	target   float64
+	miss     float64
+	incoming bool
+	throttle float64
+	rng      rng
+}
+
+func NewCPU(level Difficulty, seed uint64) *CPU {
+
+	return &CPU{
+		Level:    level,
+		settings: difficulties[level],
+		timer:    0,
+		target:   Height / 2,
+		miss:     0,
+		incoming: false,
+		throttle: 0,
+		rng:      newRNG(seed),
+	}
+}
+
+// Control steers side's paddle towards where the ball is expected to
+// arrive. The target is only recalculated every reactionDelay seconds, so
+// slower levels react late and with a larger error.
+func (c *CPU) Control(w *World, side Side) Input {
+
+	p := &w.Paddles[side]
+
+	if c.timer <= 0 {
+		c.target = c.aim(p, threat(w, p))
+		c.timer = c.settings.reactionDelay
+	}
+	c.timer -= w.Dt
+
+	return c.steer(p, w.Dt)
+}
+
+// steer moves p towards the target along whichever way it slides.
+func (c *CPU) steer(p *Paddle, dt float64) Input {
+
+	diff := c.target - (p.Y + p.H/2)
+	if p.Horizontal {
+		diff = c.target - (p.X + p.W/2)
+	}
+
+	// small dead zone so the paddle doesn't jitter around the target
+	if math.Abs(diff) < PaddleSpeed*dt {
+		c.throttle = 0
+		return Input{}
+	}
+
+	c.throttle += c.settings.maxSpeed / PaddleSpeed
+
+	if c.throttle < 1 {
+		return Input{}
+	}
+
+	c.throttle--
+
+	if p.Horizontal {
+		return Input{Left: diff < 0, Right: diff > 0}
+	}
+
+	return Input{Up: diff < 0, Down: diff > 0}
+}
+
+func (c *CPU) aim(p *Paddle, b *Ball) float64 {
+
+	// every ball is heading away or waiting to be served, drift back to the middle
+	if b == nil {
+		return c.aimAt(Height/2, false)
+	}
+
+	return c.aimAt(PredictBallY(b, p), true)
+}
+
+// aimAt is where to send the paddle for a ball predicted to land at spot.
+// The misjudgement is picked once per shot, otherwise it would just
+// average out over a few looks at the ball.
+func (c *CPU) aimAt(spot float64, incoming bool) float64 {
+
+	if !incoming {
+		c.incoming = false
+		return spot
+	}
+
+	if !c.incoming {
+		c.miss = (c.rng.float()*2 - 1) * c.settings.predictError
+		c.incoming = true
+	}
+
+	return spot + c.miss
+}
+
+// threat picks the ball that will reach p's face first, or nil if none of
+// them are heading its way.
+func threat(w *World, p *Paddle) *Ball {
+
+	var first *Ball
+	soonest := math.Inf(1)
+
+	for i := range w.Balls {
+		b := &w.Balls[i]
+
+		if b.Stuck || b.VX == 0 || (b.VX > 0) != (p.X > b.X) {
+			continue
+		}
+
+		if t := math.Abs(p.X-b.X) / math.Abs(b.VX); t < soonest {
+			first = b
+			soonest = t
+		}
+	}
+
+	return first
+}
+
+// PredictBallY works out where the centre of the ball will be once it
+// reaches the paddle's face. The walls are treated as mirrors: the ball's
+// straight line path is unfolded across the top and bottom walls and
+// folded back into the court, which gives the same bounces moveBall would.
+func PredictBallY(b *Ball, p *Paddle) float64 {
+
+	faceX := p.X - b.W
+	if b.VX < 0 {
+		faceX = p.X + p.W
+	}
+
+	t := (faceX - b.X) / b.VX
+
+	return fold(b.Y+b.VY*t, Height-b.H) + b.H/2
+}
+
+// fold brings a position unfolded across mirrors at 0 and span back into
+// [0, span].
+func fold(v, span float64) float64 {
+
+	v = math.Mod(v, 2*span)
+
+	if v < 0 {
+		v += 2 * span
+	}
+
+	if v > span {
+		v = 2*span - v
+	}
+
+	return v
+}
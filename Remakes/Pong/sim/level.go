@@ -0,0 +1,122 @@
+package sim
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// BRICK LAYOUT
+const (
+	brickTop    = 60
+	brickMargin = 20
+	brickHeight = 20
+	brickGap    = 4
+	maxRows     = 12
+	maxColumns  = 30 // any more and a brick is too thin to hit
+)
+
+// BRICK
+type Brick struct {
+	Object
+	HP    int
+	MaxHP int
+}
+
+// LEVEL
+type Level struct {
+	Name   string
+	Bricks []Brick
+}
+
+// ParseLevel reads a Breakout level from its text layout. Each line of the
+// grid is a row of bricks: a digit from 1 to 9 is a brick with that many
+// hit points, a '.' or a space is a gap. Lines starting with '#' are
+// comments and an optional "name:" line names the level. The bricks of a
+// row are stretched to fill the width of the court.
+func ParseLevel(name string, data []byte) (Level, error) {
+
+	level := Level{Name: name}
+	rows := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if title, ok := strings.CutPrefix(line, "name:"); ok {
+			level.Name = strings.TrimSpace(title)
+			continue
+		}
+
+		if len(rows) == 0 && line == "" {
+			continue
+		}
+
+		rows = append(rows, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Level{}, err
+	}
+
+	// DROP TRAILING BLANK LINES
+	for len(rows) > 0 && rows[len(rows)-1] == "" {
+		rows = rows[:len(rows)-1]
+	}
+
+	if len(rows) > maxRows {
+		return Level{}, fmt.Errorf("%v: %v rows, at most %v fit", name, len(rows), maxRows)
+	}
+
+	for r, row := range rows {
+
+		if row == "" {
+			continue
+		}
+
+		if len(row) > maxColumns {
+			return Level{}, fmt.Errorf("%v: row %v: %v columns, at most %v fit", name, r+1, len(row), maxColumns)
+		}
+
+		width := float64(Width-2*brickMargin) / float64(len(row))
+
+		for c, char := range row {
+
+			switch {
+
+			case char == '.' || char == ' ':
+				continue
+
+			case char >= '1' && char <= '9':
+				hp := int(char - '0')
+
+				level.Bricks = append(level.Bricks, Brick{
+					Object: Object{
+						X: brickMargin + float64(c)*width + brickGap/2,
+						Y: brickTop + float64(r)*(brickHeight+brickGap),
+						W: width - brickGap,
+						H: brickHeight,
+					},
+					HP:    hp,
+					MaxHP: hp,
+				})
+
+			default:
+				return Level{}, fmt.Errorf("%v: row %v: unexpected %q", name, r+1, char)
+			}
+		}
+	}
+
+	if len(level.Bricks) == 0 {
+		return Level{}, fmt.Errorf("%v: no bricks", name)
+	}
+
+	return level, nil
+}
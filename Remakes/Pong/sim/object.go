@@ -0,0 +1,162 @@
+package sim
+
+import "math"
+
+// GENERIC OBJECT STRUCT
+type Object struct {
+	X, Y, W, H float64
+}
+
+// PADDLE
+// A vertical paddle slides up and down, a horizontal one left and right.
+type Paddle struct {
+	Object
+	VX, VY     float64
+	Side       Side
+	Horizontal bool
+}
+
+// Control sets the paddle's velocity from in and moves it for dt seconds,
+// keeping it inside the court.
+func (p *Paddle) Control(in Input, dt float64) {
+
+	p.VX = 0
+	p.VY = 0
+
+	if p.Horizontal {
+
+		if in.Left {
+			p.VX -= PaddleSpeed
+		}
+
+		if in.Right {
+			p.VX += PaddleSpeed
+		}
+
+		p.X = math.Max(0, math.Min(p.X+p.VX*dt, Width-p.W))
+		return
+	}
+
+	if in.Up {
+		p.VY -= PaddleSpeed
+	}
+
+	if in.Down {
+		p.VY += PaddleSpeed
+	}
+
+	p.Y = math.Max(0, math.Min(p.Y+p.VY*dt, Height-p.H))
+}
+
+// BALL
+type Ball struct {
+	Object
+	VX, VY float64
+	Speed  float64
+	Hits   int
+	Owner  Side // last paddle to hit the ball, NoSide straight after a serve
+
+	// held by a sticky paddle, StuckY below its top, for StuckTimer seconds
+	Stuck      bool
+	StuckY     float64
+	StuckTimer float64
+}
+
+func newBall() Ball {
+
+	return Ball{
+		Object: Object{X: (Width - BallSize) / 2, Y: (Height - BallSize) / 2, W: BallSize, H: BallSize},
+		Speed:  BallSpeed,
+		Owner:  NoSide,
+	}
+}
+
+func (b *Ball) move(dx, dy float64) {
+
+	b.X += dx
+	b.Y += dy
+}
+
+// Launch sends the ball off at its current speed, angle radians away from
+// the horizontal, towards dir (-1 left, 1 right).
+func (b *Ball) Launch(angle float64, dir float64) {
+
+	b.VX = dir * b.Speed * math.Cos(angle)
+	b.VY = b.Speed * math.Sin(angle)
+}
+
+// bounceOff turns the ball away from paddle p after touching it with
+// normal c, and reports whether it was the paddle's face that was hit.
+// A ball caught on the paddle's end is sent off faster than the paddle
+// moves, so the paddle can't catch up with it again.
+func (b *Ball) bounceOff(p *Paddle, c Contact) bool {
+
+	if p.Horizontal {
+
+		if c.NY != 0 && b.VY*c.NY < 0 {
+			b.deflect(p, c.NY)
+			return true
+		}
+
+		if c.NX != 0 {
+			b.VX = c.NX * math.Max(math.Abs(b.VX), math.Abs(p.VX)+BallSpeed/4)
+		}
+
+		return false
+	}
+
+	if c.NX != 0 && b.VX*c.NX < 0 {
+		b.deflect(p, c.NX)
+		return true
+	}
+
+	if c.NY != 0 {
+		b.VY = c.NY * math.Max(math.Abs(b.VY), math.Abs(p.VY)+BallSpeed/4)
+	}
+
+	return false
+}
+
+// deflect sends the ball back off paddle p towards dir. The further from
+// the paddle's centre the ball lands the steeper it leaves, and a moving
+// paddle adds some spin in the direction it is travelling. Every
+// speedUpEvery hits the ball gets faster, up to MaxBallSpeed.
+func (b *Ball) deflect(p *Paddle, dir float64) {
+
+	b.Hits++
+
+	if b.Hits%speedUpEvery == 0 {
+		b.Speed = math.Min(b.Speed*speedUpFactor, MaxBallSpeed)
+	}
+
+	b.aimOff(p, dir)
+}
+
+// aimOff launches the ball away from p at the angle given by where on the
+// paddle it sits and how the paddle is moving. For a horizontal paddle dir
+// is -1 for up and 1 for down.
+func (b *Ball) aimOff(p *Paddle, dir float64) {
+
+	reach := (p.H + b.H) / 2
+	offset := ((b.Y + b.H/2) - (p.Y + p.H/2)) / reach
+	spin := p.VY / PaddleSpeed
+
+	if p.Horizontal {
+		reach = (p.W + b.W) / 2
+		offset = ((b.X + b.W/2) - (p.X + p.W/2)) / reach
+		spin = p.VX / PaddleSpeed
+	}
+
+	offset = math.Max(-1, math.Min(offset, 1))
+
+	angle := offset*maxBounceAngle + spin*spinAngle
+	angle = math.Max(-maxBounceAngle, math.Min(angle, maxBounceAngle))
+
+	if p.Horizontal {
+		b.VX = b.Speed * math.Sin(angle)
+		b.VY = dir * b.Speed * math.Cos(angle)
+		return
+	}
+
+	b.Launch(angle, dir)
+}
@@ -0,0 +1,247 @@
+package sim
+
+import "math"
+
+// POWER-UPS
+const (
+	pickupSize  = 24
+	pickupEvery = 6.0  // seconds between two pickups spawning
+	pickupLife  = 10.0 // seconds a pickup waits to be collected
+	maxPickups  = 2
+
+	effectTime  = 8.0  // seconds a timed effect lasts
+	stickTime   = 0.75 // seconds a sticky paddle holds on to the ball
+	bigFactor   = 1.5
+	smallFactor = 0.6
+	splitAngle  = math.Pi / 9 // angle between the balls of a multi-ball
+)
+
+type PowerUp int
+
+const (
+	MultiBall   PowerUp = iota // two extra balls split off the one that hit it
+	BigPaddle                  // grows the collector's paddle
+	SmallPaddle                // shrinks the opponent's paddle
+	Sticky                     // the collector's paddle catches the ball
+	Reverse                    // swaps the opponent's up and down
+	powerUpCount
+)
+
+func (p PowerUp) String() string {
+
+	switch p {
+	case MultiBall:
+		return "Multi-Ball"
+	case BigPaddle:
+		return "Big Paddle"
+	case SmallPaddle:
+		return "Small Paddle"
+	case Sticky:
+		return "Sticky"
+	case Reverse:
+		return "Reverse"
+	}
+
+	return "Unknown"
+}
+
+// PICKUP
+type Pickup struct {
+	Object
+	Kind PowerUp
+	Life float64
+}
+
+// EFFECT
+type Effect struct {
+	Kind      PowerUp
+	Target    Side
+	Remaining float64
+}
+
+// HasEffect reports whether kind is currently active on side.
+func (w *World) HasEffect(kind PowerUp, side Side) bool {
+
+	for _, e := range w.Effects {
+		if e.Kind == kind && e.Target == side {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updatePickups ages the pickups in court and spawns a new one every
+// pickupEvery seconds while there is room.
+func (w *World) updatePickups() {
+
+	if !w.PowerUps {
+		return
+	}
+
+	pickups := w.Pickups[:0]
+
+	for _, p := range w.Pickups {
+		p.Life -= w.Dt
+
+		if p.Life > 0 {
+			pickups = append(pickups, p)
+		}
+	}
+
+	w.Pickups = pickups
+
+	w.SpawnTimer -= w.Dt
+
+	if w.SpawnTimer > 0 {
+		return
+	}
+
+	w.SpawnTimer = pickupEvery
+
+	if len(w.Pickups) >= maxPickups {
+		return
+	}
+
+	// ANYWHERE IN THE MIDDLE THIRD OF THE COURT
+	x := Width/3 + w.rng.float()*(Width/3-pickupSize)
+	y := 40 + w.rng.float()*(Height-80-pickupSize)
+
+	w.Pickups = append(w.Pickups, Pickup{
+		Object: Object{X: x, Y: y, W: pickupSize, H: pickupSize},
+		Kind:   PowerUp(w.rng.next() % uint64(powerUpCount)),
+		Life:   pickupLife,
+	})
+}
+
+// collectPickups hands out every pickup b touches while moving by
+// (dx, dy). A ball nobody has hit yet can't collect anything.
+func (w *World) collectPickups(b *Ball, dx, dy float64) {
+
+	if b.Owner == NoSide {
+		return
+	}
+
+	pickups := w.Pickups[:0]
+	collected := []PowerUp{}
+
+	for _, p := range w.Pickups {
+
+		_, swept := Sweep(b.Object, dx, dy, p.Object)
+		_, _, inside := Overlap(b.Object, p.Object)
+
+		if swept || inside {
+			collected = append(collected, p.Kind)
+		} else {
+			pickups = append(pickups, p)
+		}
+	}
+
+	w.Pickups = pickups
+
+	for _, kind := range collected {
+		w.apply(kind, b)
+	}
+}
+
+// apply starts the effect of kind for whoever owns b.
+func (w *World) apply(kind PowerUp, b *Ball) {
+
+	switch kind {
+
+	case MultiBall:
+		for _, turn := range []float64{-splitAngle, splitAngle} {
+			extra := *b
+			sin, cos := math.Sincos(turn)
+			extra.VX = b.VX*cos - b.VY*sin
+			extra.VY = b.VX*sin + b.VY*cos
+
+			// b points into w.Balls, so the new balls join after the move
+			w.split = append(w.split, extra)
+		}
+
+	case BigPaddle, Sticky:
+		w.addEffect(kind, b.Owner)
+
+	case SmallPaddle, Reverse:
+		w.addEffect(kind, b.Owner.Opponent())
+	}
+}
+
+// addEffect starts an effect, or restarts its timer if it is already on.
+func (w *World) addEffect(kind PowerUp, target Side) {
+
+	for i := range w.Effects {
+		if w.Effects[i].Kind == kind && w.Effects[i].Target == target {
+			w.Effects[i].Remaining = effectTime
+			return
+		}
+	}
+
+	w.Effects = append(w.Effects, Effect{Kind: kind, Target: target, Remaining: effectTime})
+}
+
+// updateEffects counts down the active effects and resizes the paddles to
+// match whatever is still running.
+func (w *World) updateEffects() {
+
+	effects := w.Effects[:0]
+
+	for _, e := range w.Effects {
+		e.Remaining -= w.Dt
+
+		if e.Remaining > 0 {
+			effects = append(effects, e)
+		}
+	}
+
+	w.Effects = effects
+
+	for i := range w.Paddles {
+		p := &w.Paddles[i]
+
+		height := w.PaddleSize
+
+		if w.HasEffect(BigPaddle, p.Side) {
+			height *= bigFactor
+		}
+
+		if w.HasEffect(SmallPaddle, p.Side) {
+			height *= smallFactor
+		}
+
+		// GROW OR SHRINK AROUND THE CENTRE
+		p.Y += (p.H - height) / 2
+		p.H = height
+		p.Y = math.Max(0, math.Min(p.Y, Height-p.H))
+	}
+}
+
+// updateStuck carries stuck balls along with their paddle and lets go of
+// them once their time is up.
+func (w *World) updateStuck() {
+
+	for i := range w.Balls {
+		b := &w.Balls[i]
+
+		if !b.Stuck {
+			continue
+		}
+
+		p := &w.Paddles[b.Owner]
+
+		b.Y = p.Y + math.Max(-b.H/2, math.Min(b.StuckY, p.H-b.H/2))
+		b.StuckTimer -= w.Dt
+
+		if b.StuckTimer <= 0 {
+			b.Stuck = false
+
+			dir := 1.0
+			if p.Side == Right {
+				dir = -1
+			}
+
+			b.aimOff(p, dir)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package sim
+
+import (
+	"errors"
+	"math"
+)
+
+// FOUR PLAYERS
+const (
+	QuadCorner       = 60 // the corners are walled off this far in from both edges
+	DefaultQuadLives = 3
+)
+
+// QuadCorners are the walled off corners of the four player court.
+var QuadCorners = [4]Object{
+	{X: 0, Y: 0, W: QuadCorner, H: QuadCorner},
+	{X: Width - QuadCorner, Y: 0, W: QuadCorner, H: QuadCorner},
+	{X: 0, Y: Height - QuadCorner, W: QuadCorner, H: QuadCorner},
+	{X: Width - QuadCorner, Y: Height - QuadCorner, W: QuadCorner, H: QuadCorner},
+}
+
+// ErrTooFewSeats is NewQuad's error for a match with fewer than two sides
+// playing.
+var ErrTooFewSeats = errors.New("sim: a four player match needs at least two seats taken")
+
+// QuadWalls are the walls that close off each side's goal once it's out,
+// indexed by Side.
+var QuadWalls = [4]Object{leftWall, rightWall, topWall, bottomWall}
+
+// QUAD CONFIG
+type QuadConfig struct {
+	Dt    float64
+	Lives int     // points a side can let in before it's out
+	Seed  uint64  // seed for serve angles and who's served to first
+	Seats [4]bool // sides being played, by Side, the rest are walled off from the start
+
+	BallSpeed  float64 // speed of a served ball, 0 for BallSpeed
+	PaddleSize float64 // length of a paddle, 0 for PaddleHeight
+	ServeDelay float64 // seconds the ball waits before a serve, 0 for ServeDelay
+}
+
+// Quad is Pong for up to four: a paddle on every edge of the court, each
+// guarding its own goal. A side that lets in Lives points is out and its
+// goal is walled off, and the last side standing wins.
+type Quad struct {
+	QuadConfig
+	Paddles      [4]Paddle
+	Ball         Ball
+	LivesLeft    [4]int
+	Phase        Phase
+	ServeTimer   float64
+	ServeTo      Side
+	Winner       Side
+	Rally        int
+	LongestRally int
+	Tick         int // steps since the match started
+	rng          rng
+}
+
+// NewQuad starts a four player match. At least two seats must be taken.
+func NewQuad(cfg QuadConfig) (*Quad, error) {
+
+	seats := 0
+	for _, seated := range cfg.Seats {
+		if seated {
+			seats++
+		}
+	}
+
+	if seats < 2 {
+		return nil, ErrTooFewSeats
+	}
+
+	if cfg.Dt <= 0 {
+		cfg.Dt = DefaultStep
+	}
+
+	if cfg.Lives <= 0 {
+		cfg.Lives = DefaultQuadLives
+	}
+
+	if cfg.BallSpeed <= 0 {
+		cfg.BallSpeed = BallSpeed
+	}
+
+	if cfg.PaddleSize <= 0 {
+		cfg.PaddleSize = PaddleHeight
+	}
+
+	if cfg.ServeDelay <= 0 {
+		cfg.ServeDelay = ServeDelay
+	}
+
+	size := cfg.PaddleSize
+
+	q := &Quad{
+		QuadConfig: cfg,
+		Paddles: [4]Paddle{
+			{Object: Object{X: 20, Y: (Height - size) / 2, W: PaddleWidth, H: size}, Side: Left},
+			{Object: Object{X: Width - 20 - PaddleWidth, Y: (Height - size) / 2, W: PaddleWidth, H: size}, Side: Right},
+			{Object: Object{X: (Width - size) / 2, Y: 20, W: size, H: PaddleWidth}, Side: Top, Horizontal: true},
+			{Object: Object{X: (Width - size) / 2, Y: Height - 20 - PaddleWidth, W: size, H: PaddleWidth}, Side: Bottom, Horizontal: true},
+		},
+		Winner: NoSide,
+		rng:    newRNG(cfg.Seed),
+	}
+
+	for side, seated := range cfg.Seats {
+		if seated {
+			q.LivesLeft[side] = cfg.Lives
+		}
+	}
+
+	// THE FIRST SERVE GOES TO SOMEONE AT RANDOM
+	q.ServeTo = NoSide
+	q.serve()
+
+	return q, nil
+}
+
+// In reports whether side is still playing.
+func (q *Quad) In(side Side) bool {
+
+	return q.LivesLeft[side] > 0
+}
+
+// Step advances the match by one fixed step using each side's input.
+func (q *Quad) Step(inputs [4]Input) {
+
+	if q.Phase == MatchOver {
+		return
+	}
+
+	q.Tick++
+
+	for i := range q.Paddles {
+		if q.In(Side(i)) {
+			q.movePaddle(&q.Paddles[i], inputs[i])
+		}
+	}
+
+	switch q.Phase {
+
+	case Serving:
+		q.ServeTimer -= q.Dt
+
+		if q.ServeTimer <= 0 {
+			q.launch()
+			q.Phase = Playing
+		}
+
+	case Playing:
+		q.moveBall()
+		q.checkGoals()
+	}
+}
+
+// movePaddle moves p and keeps it between the corners.
+func (q *Quad) movePaddle(p *Paddle, in Input) {
+
+	p.Control(in, q.Dt)
+
+	if p.Horizontal {
+		p.X = math.Max(QuadCorner, math.Min(p.X, Width-QuadCorner-p.W))
+	} else {
+		p.Y = math.Max(QuadCorner, math.Min(p.Y, Height-QuadCorner-p.H))
+	}
+}
+
+// serve puts the ball back in the middle for the serve delay. It goes
+// to whoever let in the last point, or to anyone still in if they're out.
+func (q *Quad) serve() {
+
+	q.Ball = newBall()
+	q.Ball.Speed = q.BallSpeed
+	q.Rally = 0
+
+	if q.ServeTo == NoSide || !q.In(q.ServeTo) {
+
+		sides := []Side{}
+		for side := Left; side <= Bottom; side++ {
+			if q.In(side) {
+				sides = append(sides, side)
+			}
+		}
+
+		q.ServeTo = sides[int(q.rng.float()*float64(len(sides)))%len(sides)]
+	}
+
+	q.ServeTimer = q.ServeDelay
+	q.Phase = Serving
+}
+
+// launch sends the ball off towards ServeTo.
+func (q *Quad) launch() {
+
+	b := &q.Ball
+	angle := (q.rng.float()*2 - 1) * serveAngle
+
+	switch q.ServeTo {
+	case Left:
+		b.Launch(angle, -1)
+	case Right:
+		b.Launch(angle, 1)
+	case Top:
+		b.VX = b.Speed * math.Sin(angle)
+		b.VY = -b.Speed * math.Cos(angle)
+	case Bottom:
+		b.VX = b.Speed * math.Sin(angle)
+		b.VY = b.Speed * math.Cos(angle)
+	}
+}
+
+// moveBall sweeps the ball through the corners, the paddles still in and
+// the walls of the sides that are out.
+func (q *Quad) moveBall() {
+
+	b := &q.Ball
+
+	for i := range q.Paddles {
+		p := &q.Paddles[i]
+
+		if !q.In(Side(i)) {
+			continue
+		}
+
+		if c, depth, ok := Overlap(b.Object, p.Object); ok {
+			b.move(c.NX*depth, c.NY*depth)
+			q.bounce(p, c)
+		}
+	}
+
+	// SOLIDS: THE FOUR CORNERS, THEN FOR EACH SIDE ITS PADDLE OR ITS WALL
+	solids := append([]Object(nil), QuadCorners[:]...)
+
+	for i, p := range q.Paddles {
+		if q.In(Side(i)) {
+			solids = append(solids, p.Object)
+		} else {
+			solids = append(solids, QuadWalls[i])
+		}
+	}
+
+	hit := func(i int, c Contact) bool {
+
+		if i < len(QuadCorners) || !q.In(Side(i-len(QuadCorners))) {
+			reflect(b, c)
+		} else {
+			q.bounce(&q.Paddles[i-len(QuadCorners)], c)
+		}
+
+		return true
+	}
+
+	travel(b, q.Dt, solids, hit, nil)
+}
+
+func (q *Quad) bounce(p *Paddle, c Contact) {
+
+	if !q.Ball.bounceOff(p, c) {
+		return
+	}
+
+	q.Ball.Owner = p.Side
+	q.Rally++
+
+	if q.Rally > q.LongestRally {
+		q.LongestRally = q.Rally
+	}
+}
+
+// checkGoals takes a life off the side whose goal the ball went through.
+func (q *Quad) checkGoals() {
+
+	b := &q.Ball
+	conceded := NoSide
+
+	switch {
+	case b.X <= 0:
+		conceded = Left
+	case b.X >= Width-b.W:
+		conceded = Right
+	case b.Y <= 0:
+		conceded = Top
+	case b.Y >= Height-b.H:
+		conceded = Bottom
+	}
+
+	if conceded == NoSide || !q.In(conceded) {
+		return
+	}
+
+	q.LivesLeft[conceded]--
+	q.ServeTo = conceded
+
+	// THE LAST ONE IN WINS
+	left := []Side{}
+	for side := Left; side <= Bottom; side++ {
+		if q.In(side) {
+			left = append(left, side)
+		}
+	}
+
+	// THE MATCH ENDS BEFORE ANOTHER SERVE, WHICH NEEDS SOMEONE TO GO TO
+	if len(left) <= 1 {
+
+		q.Ball = newBall()
+		q.Phase = MatchOver
+
+		if len(left) == 1 {
+			q.Winner = left[0]
+		}
+
+		return
+	}
+
+	q.serve()
+}
+
+// Clone returns a copy of the match that shares nothing with q.
+func (q *Quad) Clone() *Quad {
+
+	c := *q
+
+	return &c
+}
+
+// ControlQuad steers side's paddle in a four player match the way Control
+// does in a two player one.
+func (c *CPU) ControlQuad(q *Quad, side Side) Input {
+
+	p := &q.Paddles[side]
+
+	if c.timer <= 0 {
+		c.target = c.aimQuad(q, p)
+		c.timer = c.settings.reactionDelay
+	}
+	c.timer -= q.Dt
+
+	return c.steer(p, q.Dt)
+}
+
+func (c *CPU) aimQuad(q *Quad, p *Paddle) float64 {
+
+	b := &q.Ball
+
+	if p.Horizontal {
+
+		if q.Phase != Playing || b.VY == 0 || (b.VY > 0) != (p.Y > b.Y) {
+			return c.aimAt(Width/2, false)
+		}
+
+		return c.aimAt(predictBallX(b, p), true)
+	}
+
+	if q.Phase != Playing || b.VX == 0 || (b.VX > 0) != (p.X > b.X) {
+		return c.aimAt(Height/2, false)
+	}
+
+	return c.aimAt(PredictBallY(b, p), true)
+}
+
+// predictBallX is PredictBallY for a horizontal paddle, with the ball
+// unfolded across the left and right edges.
+func predictBallX(b *Ball, p *Paddle) float64 {
+
+	faceY := p.Y - b.H
+	if b.VY < 0 {
+		faceY = p.Y + p.H
+	}
+
+	t := (faceY - b.Y) / b.VY
+
+	return fold(b.X+b.VX*t, Width-b.W) + b.W/2
+}
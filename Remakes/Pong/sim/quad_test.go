@@ -0,0 +1,89 @@
+package sim
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewQuadSeats(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		seats [4]bool
+		err   error
+	}{
+		{"none", [4]bool{}, ErrTooFewSeats},
+		{"one", [4]bool{true}, ErrTooFewSeats},
+		{"two", [4]bool{true, true}, nil},
+		{"four", [4]bool{true, true, true, true}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			if _, err := NewQuad(QuadConfig{Seats: test.seats, Lives: 1}); !errors.Is(err, test.err) {
+				t.Errorf("error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+// TestQuadPlaysOut plays matches CPU against CPU to the end, which must
+// come without a panic and with one of the seats taken as the winner.
+func TestQuadPlaysOut(t *testing.T) {
+
+	for _, seats := range [][4]bool{{true, true}, {false, true, true}, {true, false, true, true}, {true, true, true, true}} {
+		for _, lives := range []int{1, 2} {
+
+			q, err := NewQuad(QuadConfig{Seats: seats, Lives: lives, Seed: uint64(lives)})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cpus := [4]*CPU{}
+			for i := range cpus {
+				cpus[i] = NewCPU(Easy, uint64(i))
+			}
+
+			for steps := 0; q.Phase != MatchOver; steps++ {
+
+				if steps > 1_000_000 {
+					t.Fatalf("seats %v, %v lives: no winner after %v steps", seats, lives, steps)
+				}
+
+				inputs := [4]Input{}
+				for i, cpu := range cpus {
+					if q.In(Side(i)) {
+						inputs[i] = cpu.ControlQuad(q, Side(i))
+					}
+				}
+
+				q.Step(inputs)
+			}
+
+			if q.Winner == NoSide || !seats[q.Winner] || !q.In(q.Winner) {
+				t.Errorf("seats %v, %v lives: won by %v", seats, lives, q.Winner)
+			}
+		}
+	}
+}
+
+func TestQuadConfig(t *testing.T) {
+
+	q, err := NewQuad(QuadConfig{Seats: [4]bool{true, true, true, true}, BallSpeed: 120, PaddleSize: 50})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range q.Paddles {
+		if length := max(p.W, p.H); length != 50 {
+			t.Errorf("%v paddle is %v long, want 50", p.Side, length)
+		}
+	}
+
+	if q.Ball.Speed != 120 {
+		t.Errorf("ball served at %v, want 120", q.Ball.Speed)
+	}
+}
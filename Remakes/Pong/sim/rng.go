@@ -0,0 +1,32 @@
+package sim
+
+// rng is a small xorshift generator. Unlike math/rand its whole state is a
+// single number, so a World can be copied and replayed exactly.
+type rng struct {
+	state uint64
+}
+
+func newRNG(seed uint64) rng {
+
+	// xorshift gets stuck on zero
+	if seed == 0 {
+		seed = 0x9e3779b97f4a7c15
+	}
+
+	return rng{state: seed}
+}
+
+func (r *rng) next() uint64 {
+
+	r.state ^= r.state << 13
+	r.state ^= r.state >> 7
+	r.state ^= r.state << 17
+
+	return r.state
+}
+
+// float returns a number in [0, 1).
+func (r *rng) float() float64 {
+
+	return float64(r.next()>>11) / (1 << 53)
+}
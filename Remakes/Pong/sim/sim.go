@@ -0,0 +1,383 @@
+// Package sim is Pong's simulation core: paddles, ball, collisions and
+// scoring, advanced in fixed time steps. It knows nothing about ebiten, so
+// it can run headless and faster than real time.
+package sim
+
+import "math"
+
+// COURT AND SPEEDS, IN PIXELS AND PIXELS PER SECOND
+const (
+	Width  = 640
+	Height = 480
+
+	PaddleSpeed  = 360
+	BallSpeed    = 240
+	MaxBallSpeed = 720
+
+	PaddleWidth  = 20
+	PaddleHeight = 100
+	BallSize     = 20
+
+	DefaultStep   = 1.0 / 120
+	MinStep       = 1.0 / 1000 // any shorter and a frame is spent stepping, not drawing
+	DefaultTarget = 5
+	ServeDelay    = 1.0 // seconds the ball waits in the middle before a serve
+
+	// RALLY PHYSICS
+	maxBounceAngle = math.Pi / 3  // steepest angle off the paddle's edge
+	spinAngle      = math.Pi / 12 // extra angle from a paddle moving at full speed
+	serveAngle     = math.Pi / 6  // widest angle of a serve
+	speedUpEvery   = 4            // paddle hits between speed ups
+	speedUpFactor  = 1.1
+)
+
+// SIDES
+type Side int
+
+const (
+	NoSide Side = iota - 1
+	Left
+	Right
+	Top // only played in four player mode
+	Bottom
+)
+
+func (s Side) String() string {
+
+	switch s {
+	case Left:
+		return "Left"
+	case Right:
+		return "Right"
+	case Top:
+		return "Top"
+	case Bottom:
+		return "Bottom"
+	}
+
+	return "None"
+}
+
+// Opponent is the side facing s.
+func (s Side) Opponent() Side {
+
+	switch s {
+	case Left:
+		return Right
+	case Top:
+		return Bottom
+	case Bottom:
+		return Top
+	}
+
+	return Left
+}
+
+// PHASES OF A MATCH
+type Phase int
+
+const (
+	Serving Phase = iota
+	Playing
+	MatchOver
+)
+
+// INPUT FOR ONE PADDLE DURING ONE STEP
+// Vertical paddles use Up and Down, horizontal ones Left and Right.
+type Input struct {
+	Up, Down    bool
+	Left, Right bool
+}
+
+// Bits packs the input into the low four bits of a byte, for sending it
+// over the network or writing it to a replay.
+func (in Input) Bits() byte {
+
+	b := byte(0)
+
+	for i, key := range []bool{in.Up, in.Down, in.Left, in.Right} {
+		if key {
+			b |= 1 << i
+		}
+	}
+
+	return b
+}
+
+// InputFromBits is the reverse of Bits.
+func InputFromBits(b byte) Input {
+
+	return Input{
+		Up:    b&1 != 0,
+		Down:  b&2 != 0,
+		Left:  b&4 != 0,
+		Right: b&8 != 0,
+	}
+}
+
+// CONFIG
+type Config struct {
+	Dt       float64 // seconds simulated by each call to Step
+	Target   int     // points needed to win the match
+	Seed     uint64  // seed for serve angles and pickups
+	PowerUps bool    // whether pickups spawn in mid-court
+	Arena    *Arena  // obstacles in the court, nil for an open court
+
+	BallSpeed  float64 // speed of a served ball, 0 for BallSpeed
+	PaddleSize float64 // length of a paddle, 0 for PaddleHeight
+	ServeDelay float64 // seconds the ball waits before a serve, 0 for ServeDelay
+}
+
+// WORLD
+type World struct {
+	Config
+	Paddles      [2]Paddle
+	Balls        []Ball
+	Pickups      []Pickup
+	Effects      []Effect
+	Obstacles    []Obstacle
+	Score        [2]int
+	Rally        int
+	LongestRally int // longest rally of this match
+	Phase        Phase
+	ServeTimer   float64
+	ServeDir     float64
+	Winner       Side
+	Tick         int // steps since the match started
+	SpawnTimer   float64
+	split        []Ball
+	rng          rng
+}
+
+func NewWorld(cfg Config) *World {
+
+	if cfg.Dt <= 0 {
+		cfg.Dt = DefaultStep
+	}
+
+	if cfg.Target <= 0 {
+		cfg.Target = DefaultTarget
+	}
+
+	if cfg.BallSpeed <= 0 {
+		cfg.BallSpeed = BallSpeed
+	}
+
+	if cfg.PaddleSize <= 0 {
+		cfg.PaddleSize = PaddleHeight
+	}
+
+	if cfg.ServeDelay <= 0 {
+		cfg.ServeDelay = ServeDelay
+	}
+
+	w := &World{
+		Config: cfg,
+		Paddles: [2]Paddle{
+			{Object: Object{X: 20, Y: (Height - PaddleHeight) / 2, W: PaddleWidth, H: PaddleHeight}, Side: Left},
+			{Object: Object{X: Width - 20 - PaddleWidth, Y: (Height - PaddleHeight) / 2, W: PaddleWidth, H: PaddleHeight}, Side: Right},
+		},
+		rng: newRNG(cfg.Seed),
+	}
+
+	if cfg.Arena != nil {
+		w.Obstacles = append([]Obstacle(nil), cfg.Arena.Obstacles...)
+	}
+
+	w.StartMatch()
+
+	return w
+}
+
+// StartMatch clears the scores and serves the first ball to a random side.
+func (w *World) StartMatch() {
+
+	w.Score = [2]int{}
+	w.Rally = 0
+	w.LongestRally = 0
+	w.Tick = 0
+	w.placeObstacles()
+
+	w.Pickups = nil
+	w.Effects = nil
+	w.SpawnTimer = pickupEvery
+
+	for i := range w.Paddles {
+		w.Paddles[i].H = w.PaddleSize
+		w.Paddles[i].Y = (Height - w.PaddleSize) / 2
+		w.Paddles[i].VY = 0
+	}
+
+	w.ServeDir = 1
+	if w.rng.float() < 0.5 {
+		w.ServeDir = -1
+	}
+
+	w.serve()
+}
+
+// Step advances the world by one fixed step using each paddle's input.
+func (w *World) Step(inputs [2]Input) {
+
+	// A FINISHED MATCH STANDS STILL
+	if w.Phase == MatchOver {
+		return
+	}
+
+	w.Tick++
+	w.placeObstacles()
+
+	switch w.Phase {
+
+	case Serving:
+		w.updateEffects()
+		w.movePaddles(inputs)
+
+		w.ServeTimer -= w.Dt
+		if w.ServeTimer <= 0 {
+			w.Balls[0].Launch((w.rng.float()*2-1)*serveAngle, w.ServeDir)
+			w.Phase = Playing
+		}
+
+	case Playing:
+		w.updateEffects()
+		w.movePaddles(inputs)
+		w.updatePickups()
+
+		for i := range w.Balls {
+			w.moveBall(&w.Balls[i])
+		}
+
+		w.Balls = append(w.Balls, w.split...)
+		w.split = w.split[:0]
+
+		w.updateStuck()
+		w.checkGoals()
+	}
+}
+
+func (w *World) movePaddles(inputs [2]Input) {
+
+	for i := range w.Paddles {
+
+		in := inputs[i]
+
+		if w.HasEffect(Reverse, Side(i)) {
+			in.Up, in.Down = in.Down, in.Up
+		}
+
+		w.Paddles[i].Control(in, w.Dt)
+	}
+}
+
+// serve puts a single ball back in the middle for the serve delay.
+func (w *World) serve() {
+
+	w.Balls = []Ball{newBall()}
+	w.Balls[0].Speed = w.BallSpeed
+
+	w.Rally = 0
+
+	w.ServeTimer = w.ServeDelay
+	w.Phase = Serving
+}
+
+// checkGoals awards a point for every ball that has left the court on
+// either side. Once the last ball is gone the next one is served.
+func (w *World) checkGoals() {
+
+	balls := w.Balls[:0]
+
+	for _, b := range w.Balls {
+
+		switch {
+
+		case b.X <= 0:
+			w.pointScored(Right)
+
+		case b.X >= Width-b.W:
+			w.pointScored(Left)
+
+		default:
+			balls = append(balls, b)
+		}
+
+		if w.Phase == MatchOver {
+			return
+		}
+	}
+
+	w.Balls = balls
+
+	if len(w.Balls) == 0 {
+		w.serve()
+	}
+}
+
+// pointScored awards a point to side, ending the match once it reaches the
+// target. The next serve goes towards the player who conceded.
+func (w *World) pointScored(side Side) {
+
+	w.Score[side]++
+
+	if side == Left {
+		w.ServeDir = 1
+	} else {
+		w.ServeDir = -1
+	}
+
+	if w.Score[side] >= w.Target {
+		w.serve()
+		w.Winner = side
+		w.Phase = MatchOver
+	}
+}
+
+// hitPaddle is called whenever a ball bounces off a paddle's face.
+func (w *World) hitPaddle(b *Ball, p *Paddle) {
+
+	b.Owner = p.Side
+
+	w.Rally++
+
+	if w.Rally > w.LongestRally {
+		w.LongestRally = w.Rally
+	}
+
+	if w.HasEffect(Sticky, p.Side) {
+		b.Stuck = true
+		b.StuckY = b.Y - p.Y
+		b.StuckTimer = stickTime
+	}
+}
+
+// Clone returns a deep copy of the world that shares nothing with w.
+func (w *World) Clone() *World {
+
+	c := *w
+
+	c.Balls = append([]Ball(nil), w.Balls...)
+	c.Pickups = append([]Pickup(nil), w.Pickups...)
+	c.Effects = append([]Effect(nil), w.Effects...)
+	c.Obstacles = append([]Obstacle(nil), w.Obstacles...)
+	c.split = nil
+
+	return &c
+}
+
+// Restore turns w back into a copy of s. Unlike Clone it reuses w's own
+// slices, so a World kept around as a snapshot can be saved into and
+// restored from every step without making garbage.
+func (w *World) Restore(s *World) {
+
+	balls, pickups, effects, split := w.Balls[:0], w.Pickups[:0], w.Effects[:0], w.split[:0]
+	obstacles := w.Obstacles[:0]
+
+	*w = *s
+
+	w.Balls = append(balls, s.Balls...)
+	w.Pickups = append(pickups, s.Pickups...)
+	w.Effects = append(effects, s.Effects...)
+	w.Obstacles = append(obstacles, s.Obstacles...)
+	w.split = split
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+const (
+	statsFile    = "stats.json"
+	statsVersion = 1
+	maxHistory   = 50
+	shownHistory = 12
+)
+
+// MATCH RECORD
+type MatchRecord struct {
+	Date         time.Time `json:"date"`
+	Mode         string    `json:"mode"`
+	Left         int       `json:"left"`
+	Right        int       `json:"right"`
+	Winner       string    `json:"winner"`
+	Duration     float64   `json:"duration"` // seconds of play
+	LongestRally int       `json:"longest_rally"`
+}
+
+// BESTS FOR ONE MODE
+type ModeStats struct {
+	Played       int `json:"played"`
+	Wins         int `json:"wins"` // matches won by the player at this keyboard
+	HighScore    int `json:"high_score"`
+	LongestRally int `json:"longest_rally"`
+}
+
+// STATS
+type Stats struct {
+	Version int                   `json:"version"`
+	Modes   map[string]*ModeStats `json:"modes"`
+	History []MatchRecord         `json:"history"` // newest first
+	path    string
+}
+
+// statsPath is where the stats live: a pong folder under the user's config
+// directory, falling back to the working directory when there isn't one.
+func statsPath() string {
+
+	dir, err := os.UserConfigDir()
+
+	if err != nil {
+		return statsFile
+	}
+
+	return filepath.Join(dir, "pong", statsFile)
+}
+
+func newStats(path string) *Stats {
+
+	return &Stats{
+		Version: statsVersion,
+		Modes:   map[string]*ModeStats{},
+		History: []MatchRecord{},
+		path:    path,
+	}
+}
+
+// LoadStats reads the stats at path. A missing file just means a fresh
+// start. A file that can't be read as stats is moved aside to path.bad,
+// so it isn't overwritten, and the stats start over.
+func LoadStats(path string) *Stats {
+
+	stats := newStats(path)
+
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats
+	}
+
+	if err == nil {
+		err = json.Unmarshal(data, stats)
+	}
+
+	if err == nil && stats.Version != statsVersion {
+		err = fmt.Errorf("unknown version %v", stats.Version)
+	}
+
+	if err != nil {
+		log.Printf("stats: %v is unreadable (%v), starting over", path, err)
+
+		if renameErr := os.Rename(path, path+".bad"); renameErr != nil {
+			log.Printf("stats: %v", renameErr)
+		}
+
+		return newStats(path)
+	}
+
+	if stats.Modes == nil {
+		stats.Modes = map[string]*ModeStats{}
+	}
+
+	if stats.History == nil {
+		stats.History = []MatchRecord{}
+	}
+
+	// DROP ANY NULL ENTRIES A HAND EDIT MAY HAVE LEFT
+	for mode, m := range stats.Modes {
+		if m == nil {
+			delete(stats.Modes, mode)
+		}
+	}
+
+	return stats
+}
+
+// Save writes the stats to disk.
+func (s *Stats) Save() error {
+
+	return saveJSON(s.path, s)
+}
+
+// saveJSON writes v to a temporary file first and renames it over the old
+// one, so a crash half way through never leaves a broken file behind.
+func saveJSON(path string, v any) error {
+
+	data, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func (s *Stats) mode(name string) *ModeStats {
+
+	m, ok := s.Modes[name]
+
+	if !ok {
+		m = &ModeStats{}
+		s.Modes[name] = m
+	}
+
+	return m
+}
+
+// Record adds a finished match to the history and the bests of its mode.
+// score is the points that count towards the mode's high score.
+func (s *Stats) Record(r MatchRecord, won bool, score int) {
+
+	m := s.mode(r.Mode)
+
+	m.Played++
+
+	if won {
+		m.Wins++
+	}
+
+	if score > m.HighScore {
+		m.HighScore = score
+	}
+
+	if r.LongestRally > m.LongestRally {
+		m.LongestRally = r.LongestRally
+	}
+
+	s.History = append([]MatchRecord{r}, s.History...)
+
+	if len(s.History) > maxHistory {
+		s.History = s.History[:maxHistory]
+	}
+
+	if err := s.Save(); err != nil {
+		log.Printf("stats: %v", err)
+	}
+}
+
+// LongestRally is the longest rally ever played in any mode.
+func (s *Stats) LongestRally() int {
+
+	longest := 0
+
+	for _, m := range s.Modes {
+		if m.LongestRally > longest {
+			longest = m.LongestRally
+		}
+	}
+
+	return longest
+}
+
+// STATS SCREEN
+func (g *Game) updateStats() {
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.state = stateMenu
+	}
+}
+
+func (g *Game) drawStats(screen *ebiten.Image) {
+
+	text.Draw(screen, "STATS", basicfont.Face7x13, screenWidth/2-17, 30, color.White)
+
+	// BESTS PER MODE
+	modes := []string{}
+	for mode := range g.stats.Modes {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+
+	header := fmt.Sprintf("%-20v %6v %6v %10v %8v", "Mode", "Played", "Wins", "High Score", "Rally")
+	text.Draw(screen, header, basicfont.Face7x13, 20, 70, color.RGBA{150, 200, 200, 1})
+
+	y := 90
+
+	for _, mode := range modes {
+		m := g.stats.Modes[mode]
+
+		row := fmt.Sprintf("%-20v %6v %6v %10v %8v", mode, m.Played, m.Wins, m.HighScore, m.LongestRally)
+		text.Draw(screen, row, basicfont.Face7x13, 20, y, color.White)
+		y += 16
+	}
+
+	// RECENT MATCHES
+	y += 24
+	text.Draw(screen, "Recent Matches", basicfont.Face7x13, 20, y, color.RGBA{150, 200, 200, 1})
+	y += 20
+
+	for i, r := range g.stats.History {
+
+		if i == shownHistory {
+			break
+		}
+
+		row := fmt.Sprintf("%v  %-18v %3v - %-3v %-12v %4.0fs  rally %v",
+			r.Date.Format("2006-01-02 15:04"), r.Mode, r.Left, r.Right, r.Winner, r.Duration, r.LongestRally)
+		text.Draw(screen, row, basicfont.Face7x13, 20, y, color.White)
+		y += 16
+	}
+
+	if len(g.stats.History) == 0 {
+		text.Draw(screen, "No matches played yet.", basicfont.Face7x13, 20, y, color.White)
+	}
+
+	text.Draw(screen, "Esc - Menu", basicfont.Face7x13, screenWidth/2-35, screenHeight-20, color.RGBA{100, 200, 250, 1})
+}
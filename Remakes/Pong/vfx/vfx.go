@@ -0,0 +1,263 @@
+// Package vfx draws effects that only decorate a game: particle bursts,
+// motion trails and screen shake. Nothing here feeds back into the
+// simulation, so it can use its own randomness and real time, and be
+// switched off without changing how a match plays out.
+package vfx
+
+import (
+	"image/color"
+	"math"
+	"math/rand/v2"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// DEFAULTS
+const (
+	TrailLife    = 0.12 // seconds a trail point lasts
+	particleDrag = 3.0  // how fast particles slow down, per second
+	maxParticles = 2000
+)
+
+// PARTICLE
+type particle struct {
+	X, Y   float64
+	VX, VY float64
+	Size   float64
+	Life   float64 // seconds left
+	TTL    float64 // seconds it lived for in all
+	Color  color.RGBA
+}
+
+// TRAIL
+// A trail is where something has been lately, oldest point first.
+type trail struct {
+	points []trailPoint
+}
+
+type trailPoint struct {
+	x, y, w, h float64
+	age        float64
+}
+
+// LAYER
+// A Layer holds every effect running on one screen. Update it once a tick
+// with the seconds that passed and draw it over or under the game.
+type Layer struct {
+	particles []particle
+	trails    map[int]*trail
+	shake     float64 // strength in pixels
+	shakeLeft float64 // seconds left
+	shakeTTL  float64
+	offsetX   float64
+	offsetY   float64
+	rng       *rand.Rand
+}
+
+// New returns an empty layer.
+func New(seed uint64) *Layer {
+
+	return &Layer{
+		trails: map[int]*trail{},
+		rng:    rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+	}
+}
+
+// Burst throws n particles out of (x, y) in every direction at up to speed
+// pixels a second.
+func (l *Layer) Burst(x, y float64, n int, clr color.RGBA, speed float64) {
+
+	l.Spray(x, y, n, clr, speed, 0, math.Pi)
+}
+
+// Spray throws n particles out of (x, y) within spread radians either side
+// of angle.
+func (l *Layer) Spray(x, y float64, n int, clr color.RGBA, speed, angle, spread float64) {
+
+	for range n {
+
+		if len(l.particles) >= maxParticles {
+			return
+		}
+
+		a := angle + (l.rng.Float64()*2-1)*spread
+		v := speed * (0.3 + 0.7*l.rng.Float64())
+		life := 0.25 + 0.35*l.rng.Float64()
+
+		l.particles = append(l.particles, particle{
+			X:     x,
+			Y:     y,
+			VX:    math.Cos(a) * v,
+			VY:    math.Sin(a) * v,
+			Size:  2 + 2*l.rng.Float64(),
+			Life:  life,
+			TTL:   life,
+			Color: clr,
+		})
+	}
+}
+
+// Shake shakes the screen by up to strength pixels, dying away over
+// seconds. A weaker shake doesn't cut a stronger one short.
+func (l *Layer) Shake(strength, seconds float64) {
+
+	if l.shakeLeft <= 0 || strength >= l.shake*l.shakeLeft/l.shakeTTL {
+		l.shake = strength
+		l.shakeLeft = seconds
+		l.shakeTTL = seconds
+	}
+}
+
+// Offset is how far to move the screen for the shake right now.
+func (l *Layer) Offset() (float64, float64) {
+
+	return l.offsetX, l.offsetY
+}
+
+// Shaking reports whether the screen is being shaken.
+func (l *Layer) Shaking() bool {
+
+	return l.shakeLeft > 0
+}
+
+// Mark adds the box (x, y, w, h) to trail id, starting the trail if needed.
+func (l *Layer) Mark(id int, x, y, w, h float64) {
+
+	t := l.trails[id]
+
+	if t == nil {
+		t = &trail{}
+		l.trails[id] = t
+	}
+
+	t.points = append(t.points, trailPoint{x: x, y: y, w: w, h: h})
+}
+
+// Cut ends trail id, so its next point doesn't join on to the last one.
+func (l *Layer) Cut(id int) {
+
+	delete(l.trails, id)
+}
+
+// Clear stops every effect.
+func (l *Layer) Clear() {
+
+	l.particles = l.particles[:0]
+	clear(l.trails)
+	l.shakeLeft = 0
+	l.offsetX, l.offsetY = 0, 0
+}
+
+// Update moves every effect on by dt seconds.
+func (l *Layer) Update(dt float64) {
+
+	// PARTICLES
+	alive := l.particles[:0]
+	drag := math.Exp(-particleDrag * dt)
+
+	for _, p := range l.particles {
+
+		p.Life -= dt
+
+		if p.Life <= 0 {
+			continue
+		}
+
+		p.X += p.VX * dt
+		p.Y += p.VY * dt
+		p.VX *= drag
+		p.VY *= drag
+
+		alive = append(alive, p)
+	}
+
+	l.particles = alive
+
+	// TRAILS
+	for id, t := range l.trails {
+
+		points := t.points[:0]
+
+		for _, p := range t.points {
+
+			p.age += dt
+
+			if p.age < TrailLife {
+				points = append(points, p)
+			}
+		}
+
+		t.points = points
+
+		if len(t.points) == 0 {
+			delete(l.trails, id)
+		}
+	}
+
+	// SHAKE, FADING OUT
+	l.offsetX, l.offsetY = 0, 0
+
+	if l.shakeLeft > 0 {
+
+		l.shakeLeft -= dt
+		strength := l.shake * math.Max(l.shakeLeft, 0) / l.shakeTTL
+
+		l.offsetX = (l.rng.Float64()*2 - 1) * strength
+		l.offsetY = (l.rng.Float64()*2 - 1) * strength
+	}
+}
+
+// Draw draws the trails and particles moved by (dx, dy).
+func (l *Layer) Draw(screen *ebiten.Image, dx, dy float64) {
+
+	for _, t := range l.trails {
+		for _, p := range t.points {
+
+			fade := 1 - p.age/TrailLife
+			w, h := p.w*(0.4+0.6*fade), p.h*(0.4+0.6*fade)
+			x, y := p.x+(p.w-w)/2+dx, p.y+(p.h-h)/2+dy
+
+			vector.DrawFilledRect(screen, float32(x), float32(y), float32(w), float32(h), Fade(color.RGBA{255, 255, 255, 255}, 0.5*fade), false)
+		}
+	}
+
+	for _, p := range l.particles {
+
+		x, y := p.X-p.Size/2+dx, p.Y-p.Size/2+dy
+		vector.DrawFilledRect(screen, float32(x), float32(y), float32(p.Size), float32(p.Size), Fade(p.Color, p.Life/p.TTL), false)
+	}
+}
+
+// Fade makes clr a times as opaque. ebiten wants premultiplied alpha, so
+// every channel scales.
+func Fade(clr color.RGBA, a float64) color.RGBA {
+
+	a = math.Max(0, math.Min(a, 1))
+
+	return color.RGBA{
+		R: uint8(float64(clr.R) * a),
+		G: uint8(float64(clr.G) * a),
+		B: uint8(float64(clr.B) * a),
+		A: uint8(float64(clr.A) * a),
+	}
+}
+
+// DashedLine draws a dashed line from (x0, y0) to (x1, y1), dash pixels on
+// and gap pixels off.
+func DashedLine(screen *ebiten.Image, x0, y0, x1, y1, dash, gap, width float64, clr color.Color) {
+
+	length := math.Hypot(x1-x0, y1-y0)
+
+	if length == 0 || dash <= 0 {
+		return
+	}
+
+	ux, uy := (x1-x0)/length, (y1-y0)/length
+
+	for d := 0.0; d < length; d += dash + gap {
+
+		end := math.Min(d+dash, length)
+		vector.StrokeLine(screen, float32(x0+ux*d), float32(y0+uy*d), float32(x0+ux*end), float32(y0+uy*end), float32(width), clr, false)
+	}
+}
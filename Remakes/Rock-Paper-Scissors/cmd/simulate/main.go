@@ -0,0 +1,88 @@
+// Command simulate plays the computer's strategies against each other and
+// against simple human habits, headless, and prints how often each one
+// wins:
+//
+//	go run ./cmd/simulate -rounds 10000
+//	go run ./cmd/simulate -ai meta -vs cycle,win-stay
+//	go run ./cmd/simulate -rules rules/2-lizard-spock.txt
+//
+// Every cell is the row strategy's win and loss rate against the column.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+
+	"Rock-Paper-Scissors/rps"
+)
+
+func main() {
+
+	ai := flag.String("ai", strings.Join(rps.Adaptive(), ","), "strategies to measure, comma separated")
+	vs := flag.String("vs", strings.Join(rps.Names(), ","), "strategies to play them against")
+	rounds := flag.Int("rounds", 5000, "rounds in every session")
+	sessions := flag.Int("sessions", 4, "sessions played for every pairing")
+	seed := flag.Int64("seed", 1, "seed for every strategy's randomness")
+	rulesPath := flag.String("rules", "", "rules file to play by, plain Rock-Paper-Scissors if empty")
+	flag.Parse()
+
+	rules := rps.Classic
+
+	if *rulesPath != "" {
+
+		var err error
+		rules, err = rps.LoadRules(*rulesPath)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	rows, cols := strings.Split(*ai, ","), strings.Split(*vs, ",")
+
+	// CHECK THE NAMES BEFORE PLAYING ANYTHING
+	for _, name := range append(rows, cols...) {
+		if _, err := rps.New(name, rules, nil); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	fmt.Printf("%v: %v sessions of %v rounds, win%% / loss%% of the row strategy\n\n", rules.Name, *sessions, *rounds)
+
+	fmt.Printf("%-12v", "")
+	for _, col := range cols {
+		fmt.Printf("%13v", col)
+	}
+	fmt.Println()
+
+	for _, row := range rows {
+
+		fmt.Printf("%-12v", row)
+
+		for j, col := range cols {
+
+			total := rps.Tally{}
+
+			for s := range *sessions {
+
+				rng := rand.New(rand.NewSource(*seed + int64(s*len(cols)+j)))
+				a, _ := rps.New(row, rules, rng)
+				b, _ := rps.New(col, rules, rng)
+
+				t := rps.Duel(rules, a, b, *rounds)
+				total.Wins += t.Wins
+				total.Losses += t.Losses
+				total.Draws += t.Draws
+			}
+
+			fmt.Printf("%13v", fmt.Sprintf("%.1f / %.1f", 100*total.WinRate(), 100*total.LossRate()))
+		}
+
+		fmt.Println()
+	}
+}
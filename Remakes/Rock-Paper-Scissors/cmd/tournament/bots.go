@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math/rand"
+
+	"Rock-Paper-Scissors/rps"
+)
+
+// BOTS ENTERED FROM HERE
+// Any type with Throw and Observe can play. Register it under a name in
+// init and it's in every tournament, and can be picked with -only.
+func init() {
+
+	rps.Register("mirror", "throws whatever the other side threw last", func(rules *rps.Rules, rng *rand.Rand) rps.Strategy {
+		return &mirror{rules: rules, rng: rng}
+	})
+
+	rps.Register("stubborn", "sticks to one move picked at the start", func(rules *rps.Rules, rng *rand.Rand) rps.Strategy {
+		return &stubborn{move: rules.Random(rng)}
+	})
+}
+
+// mirror copies the other side's last move.
+type mirror struct {
+	last   rps.Move
+	played bool
+	rules  *rps.Rules
+	rng    *rand.Rand
+}
+
+func (m *mirror) Throw() rps.Move {
+
+	if !m.played {
+		return m.rules.Random(m.rng)
+	}
+
+	return m.last
+}
+
+func (m *mirror) Observe(mine, theirs rps.Move) {
+
+	m.last, m.played = theirs, true
+}
+
+// stubborn never changes its mind.
+type stubborn struct {
+	move rps.Move
+}
+
+func (s *stubborn) Throw() rps.Move {
+
+	return s.move
+}
+
+func (s *stubborn) Observe(mine, theirs rps.Move) {}
@@ -0,0 +1,95 @@
+// Command tournament plays every registered strategy against every other
+// one, headless, and prints a leaderboard:
+//
+//	go run ./cmd/tournament -rounds 500 -duels 20
+//	go run ./cmd/tournament -rules rules/3-seven.txt -only random,meta,mirror
+//
+// Rounds are judged by the same Rules.Judge the game uses. Strategies of
+// your own go in this folder as Go types, registered with rps.Register
+// from an init function the way bots.go does it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"Rock-Paper-Scissors/rps"
+)
+
+func main() {
+
+	rounds := flag.Int("rounds", 500, "rounds in every duel")
+	duels := flag.Int("duels", 10, "duels, each started afresh, in every pairing")
+	rulesPath := flag.String("rules", "", "rules file to play by, plain Rock-Paper-Scissors if empty")
+	only := flag.String("only", "", "strategies to enter, comma separated, every registered one if empty")
+	seed := flag.Int64("seed", 1, "seed for the whole tournament")
+	table := flag.Bool("table", true, "print how every strategy did against every other")
+	flag.Parse()
+
+	rules := rps.Classic
+
+	if *rulesPath != "" {
+
+		var err error
+		rules, err = rps.LoadRules(*rulesPath)
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	names := rps.Names()
+	if *only != "" {
+		names = strings.Split(*only, ",")
+	}
+
+	t, err := rps.RoundRobin(rules, names, *rounds, *duels, *seed)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	fmt.Printf("%v: %v strategies, %v duels of %v rounds a pairing\n", rules.Name, len(names), *duels, *rounds)
+	fmt.Printf("a win is a point and a draw half, 95%% confidence intervals from the spread between duels\n\n")
+
+	fmt.Printf("%4v  %-12v %7v  %-17v %8v %8v %8v\n", "", "strategy", "score", "interval", "won", "lost", "drawn")
+
+	for i, s := range t.Leaderboard {
+		fmt.Printf("%4v  %-12v %7.4f  [%.4f, %.4f] %8v %8v %8v\n", i+1, s.Name, s.Score, s.Low, s.High, s.Wins, s.Losses, s.Draws)
+	}
+
+	// THE ROW STRATEGY'S WINS LESS LOSSES PER HUNDRED ROUNDS AGAINST THE COLUMN
+	if !*table {
+		return
+	}
+
+	fmt.Printf("\nwins less losses per 100 rounds, row against column\n\n%-12v", "")
+
+	for _, s := range t.Leaderboard {
+		fmt.Printf("%10v", s.Name)
+	}
+	fmt.Println()
+
+	for _, row := range t.Leaderboard {
+
+		fmt.Printf("%-12v", row.Name)
+
+		for _, col := range t.Leaderboard {
+
+			tally, ok := t.Against(row.Name, col.Name)
+
+			if !ok {
+				fmt.Printf("%10v", "-")
+				continue
+			}
+
+			fmt.Printf("%10.1f", 100*(tally.WinRate()-tally.LossRate()))
+		}
+
+		fmt.Println()
+	}
+}
@@ -0,0 +1,74 @@
+// Command verify checks the transcript of a fair session against the
+// computer, the file the game saves when the seed is revealed:
+//
+//	go run ./cmd/verify -hash 3f9a... fair-20261018-153000.json
+//
+// The hash is the one the game showed before the first round. Without it
+// verify only checks that the transcript holds together, not that it's
+// the session that was promised.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"Rock-Paper-Scissors/rps"
+)
+
+// minHashPrefix is the least of the hash -hash takes. Fewer characters
+// would be too easy to match with a made up seed.
+const minHashPrefix = 16
+
+func main() {
+
+	hash := flag.String("hash", "", "the seed hash shown before the session, or its start")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: verify [-hash seed-hash] transcript.json")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(flag.Arg(0))
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	t := &rps.Transcript{}
+
+	if err := json.Unmarshal(data, t); err != nil {
+		fmt.Fprintf(os.Stderr, "%v: %v\n", flag.Arg(0), err)
+		os.Exit(2)
+	}
+
+	// A PREFIX IS ENOUGH, FOR WHOEVER ONLY WROTE DOWN THE START OF IT
+	if *hash != "" && len(*hash) < minHashPrefix {
+		fmt.Fprintf(os.Stderr, "give at least %v characters of the hash\n", minHashPrefix)
+		os.Exit(2)
+	}
+
+	if *hash != "" && !strings.HasPrefix(t.Hash, strings.ToLower(*hash)) {
+		fmt.Fprintf(os.Stderr, "UNFAIR: the transcript's hash %v isn't the one shown, %v\n", t.Hash, *hash)
+		os.Exit(1)
+	}
+
+	if err := t.Check(); err != nil {
+		fmt.Fprintln(os.Stderr, "UNFAIR:", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("fair: all %v of %v's throws follow from seed %v, which matches hash %v\n", len(t.Throws), t.Strategy, t.Seed, t.Hash)
+
+	if *hash == "" {
+		fmt.Println("check the hash is the one the game showed before the first round with -hash")
+	}
+}
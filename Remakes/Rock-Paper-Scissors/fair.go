@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"Rock-Paper-Scissors/rps"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// FAIR PLAY
+// With -fair the computer's seed is sealed before the session. Its hash
+// shows from the first round, and the seed only once the player asks for
+// it, with a transcript of every round saved for cmd/verify to check. The
+// rules and the computer's strategy are sealed in too, so they can't be
+// changed until the seed is out.
+const fairDir = "fair" // next to the stats
+
+// how a sealed session ended
+type fair_end struct {
+	transcript *rps.Transcript
+	path       string // where the transcript went
+	err        error  // why it couldn't be saved
+}
+
+// StartFair seals a new seed and starts the computer's strategy afresh
+// from it.
+func (g *Game) StartFair() error {
+
+	t, err := rps.NewTranscript(g.rules, g.strategy_name)
+
+	if err != nil {
+		return err
+	}
+
+	// THE STRATEGY HAS TO BE MADE FROM THE SEED BEFORE ANYTHING ELSE
+	// DRAWS ON IT, THE WAY THE CHECK MAKES IT
+	g.rng = t.Rand()
+
+	if err := g.SetStrategy(g.strategy_name); err != nil {
+		return err
+	}
+
+	g.fair = t
+	g.ai_button.str = "Reveal Seed"
+	g.NewMatch()
+
+	log.Printf("fair play: the seed hash is %v", t.Hash)
+
+	return nil
+}
+
+// EndFair reveals the seed and saves the transcript.
+func (g *Game) EndFair() {
+
+	t := g.fair
+	g.fair = nil
+
+	path, err := saveTranscript(t)
+
+	if err != nil {
+		log.Printf("fair play: %v", err)
+	} else {
+		log.Printf("fair play: the seed was %v, transcript saved to %v", t.Seed, path)
+	}
+
+	g.fair_end = &fair_end{t, path, err}
+	g.state = stateFairEnd
+}
+
+// saveTranscript writes t to a file of its own in the fair folder, named
+// for when the session ended.
+func saveTranscript(t *rps.Transcript) (string, error) {
+
+	dir := filepath.Join(filepath.Dir(statsPath()), fairDir)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(t, "", "  ")
+
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, "fair-"+time.Now().Format("20060102-150405")+".json")
+
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// UpdateFairEnd waits on the revealed seed, then seals a new one.
+func (g *Game) UpdateFairEnd() {
+
+	if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButton0) && !inpututil.IsKeyJustPressed(ebiten.KeyEnter) && !inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		return
+	}
+
+	g.fair_end = nil
+	g.state = stateGame
+
+	if err := g.StartFair(); err != nil {
+		log.Printf("fair play: %v, playing on unsealed", err)
+		g.ai_button.str = "AI: " + g.strategy_name
+	}
+}
+
+func (g *Game) DrawFairEnd(screen *ebiten.Image) {
+
+	end := g.fair_end
+	t := end.transcript
+
+	text.Draw(screen, "The Seed, Revealed", game_font, 10, 36, color.White)
+
+	rows := [][2]string{
+		{"Seed hash, shown from the start", t.Hash[:32]},
+		{"", t.Hash[32:]},
+		{"Seed", fmt.Sprint(t.Seed)},
+		{"Salt", t.Salt[:32]},
+		{"", t.Salt[32:]},
+		{"Strategy", t.Strategy},
+		{"Rounds", fmt.Sprint(len(t.Throws))},
+	}
+
+	for i, row := range rows {
+		text.Draw(screen, row[0], small_font, 10, 80+i*24, stats_label)
+		text.Draw(screen, row[1], small_font, 300, 80+i*24, color.White)
+	}
+
+	lines := []string{"Every throw can be checked against the seed with:", "go run ./cmd/verify -hash " + t.Hash[:16] + " " + filepath.Base(end.path), "The transcript is in " + filepath.Dir(end.path)}
+
+	if end.err != nil {
+		lines = []string{"The transcript couldn't be saved:", end.err.Error()}
+	}
+
+	for i, line := range lines {
+		text.Draw(screen, line, small_font, 10, 290+i*24, color.White)
+	}
+
+	text.Draw(screen, "Click for a new sealed session", small_font, 10, 440, stats_label)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// HANDS
+// Every hand is drawn once, pointing right, the first time it's needed.
+// Moves without a hand of their own are thrown as a fist, with the move's
+// name under it.
+const (
+	handWidth  = 140
+	handHeight = 100
+	cuffWidth  = 22
+)
+
+var (
+	skin       = color.RGBA{240, 195, 150, 255}
+	skin_shade = color.RGBA{215, 165, 120, 255}
+	skin_line  = color.RGBA{160, 110, 80, 255}
+
+	hand_images = map[string]*ebiten.Image{}
+	cuff_image  *ebiten.Image
+)
+
+// the hands there are pictures of, by lower case move name
+var hand_shapes = map[string]func(*ebiten.Image){
+	"rock":     drawFist,
+	"paper":    drawPaper,
+	"scissors": drawScissors,
+	"lizard":   drawLizard,
+	"spock":    drawSpock,
+}
+
+// handImage is the picture of the hand throwing move, a fist if there
+// isn't one.
+func handImage(move string) *ebiten.Image {
+
+	name := strings.ToLower(move)
+
+	if _, ok := hand_shapes[name]; !ok {
+		name = "rock"
+	}
+
+	if img, ok := hand_images[name]; ok {
+		return img
+	}
+
+	img := ebiten.NewImage(handWidth, handHeight)
+	hand_shapes[name](img)
+	hand_images[name] = img
+
+	return img
+}
+
+// DrawHand draws the hand throwing move centred on x, y and scaled by
+// scale, pointing left when flip is set, with a cuff of the side's colour.
+func DrawHand(screen *ebiten.Image, move string, x, y, scale float64, flip bool, cuff color.Color) {
+
+	if cuff_image == nil {
+		cuff_image = ebiten.NewImage(cuffWidth+4, 40)
+		cuff_image.Fill(color.White)
+	}
+
+	place := func(op *ebiten.DrawImageOptions) {
+
+		op.GeoM.Translate(-handWidth/2, -handHeight/2)
+
+		if flip {
+			op.GeoM.Scale(-scale, scale)
+		} else {
+			op.GeoM.Scale(scale, scale)
+		}
+
+		op.GeoM.Translate(x, y)
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	place(op)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(handImage(move), op)
+
+	// THE CUFF GOES OVER THE WRIST
+	op = &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(0, 36)
+	place(op)
+	op.ColorScale.ScaleWithColor(cuff)
+	screen.DrawImage(cuff_image, op)
+}
+
+// palm is the back of the hand every shape starts from.
+func palm(img *ebiten.Image) {
+
+	vector.DrawFilledRect(img, cuffWidth, 30, 58, 52, skin, true)
+	vector.DrawFilledCircle(img, cuffWidth+4, 56, 26, skin, true)
+}
+
+// finger runs from the knuckles at x0, y0 out to a rounded tip at x1, y1.
+func finger(img *ebiten.Image, x0, y0, x1, y1, width float32) {
+
+	// A SHADED EDGE KEEPS FINGERS SIDE BY SIDE APART
+	vector.StrokeLine(img, x0, y0, x1, y1, width, skin_shade, true)
+	vector.DrawFilledCircle(img, x1, y1, width/2, skin_shade, true)
+
+	vector.StrokeLine(img, x0, y0, x1, y1, width-3, skin, true)
+	vector.DrawFilledCircle(img, x1, y1, width/2-1.5, skin, true)
+}
+
+// curled is a finger folded into the palm, only its knuckle showing.
+func curled(img *ebiten.Image, y float32) {
+
+	vector.DrawFilledCircle(img, 82, y, 8, skin_shade, true)
+	vector.DrawFilledCircle(img, 81, y, 7, skin, true)
+}
+
+// thumb lies along the top of the hand, or sticks up when out.
+func thumb(img *ebiten.Image, out bool) {
+
+	if out {
+		finger(img, 50, 34, 86, 12, 13)
+		return
+	}
+
+	vector.StrokeLine(img, 38, 31, 80, 31, 12, skin_shade, true)
+	vector.StrokeLine(img, 38, 30, 78, 30, 10, skin, true)
+}
+
+func drawFist(img *ebiten.Image) {
+
+	palm(img)
+
+	for _, y := range []float32{38, 52, 66, 80} {
+		curled(img, y)
+	}
+
+	thumb(img, false)
+}
+
+func drawPaper(img *ebiten.Image) {
+
+	palm(img)
+
+	for _, y := range []float32{38, 51, 64, 77} {
+		finger(img, 70, y, 130, y, 12)
+	}
+
+	thumb(img, true)
+}
+
+func drawScissors(img *ebiten.Image) {
+
+	palm(img)
+
+	finger(img, 70, 38, 130, 18, 12)
+	finger(img, 70, 51, 130, 58, 12)
+
+	curled(img, 66)
+	curled(img, 80)
+
+	thumb(img, false)
+}
+
+func drawLizard(img *ebiten.Image) {
+
+	palm(img)
+
+	// THE FINGERS ARE THE HEAD, THE THUMB UNDER THEM THE JAW
+	finger(img, 66, 48, 130, 52, 26)
+	finger(img, 60, 80, 122, 68, 12)
+
+	vector.DrawFilledCircle(img, 112, 45, 3, skin_line, true)
+}
+
+func drawSpock(img *ebiten.Image) {
+
+	palm(img)
+
+	// TWO FINGERS EITHER SIDE OF THE GAP
+	finger(img, 70, 38, 130, 26, 11)
+	finger(img, 70, 49, 130, 38, 11)
+	finger(img, 70, 66, 130, 70, 11)
+	finger(img, 70, 77, 130, 82, 11)
+
+	thumb(img, true)
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"image/color"
 	"log"
 	"math/rand"
 	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"Rock-Paper-Scissors/rps"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -16,7 +22,8 @@ import (
 )
 
 var (
-	game_font font.Face
+	game_font  font.Face
+	small_font font.Face
 )
 
 const (
@@ -24,8 +31,23 @@ const (
 	screenHeight = 480
 	buttonWidth  = 200
 	buttonHeight = 40
+
+	// THE LINES OF TEXT UNDER THE BUTTONS
+	textLines  = 7
+	textBottom = 400
+)
+
+// SCREENS
+const (
+	stateGame = iota
+	stateStats
+	stateConnecting
+	stateFairEnd
 )
 
+// matches are best of one of these
+var best_of_choices = []int{3, 5, 7}
+
 func init() {
 
 	ttfData, err := os.ReadFile("assets/font.ttf")
@@ -49,16 +71,65 @@ func init() {
 	if err != nil {
 		log.Fatal(err)
 	}
-}
 
-func generateRandom() int {
+	small_font, err = opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    16,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
 
-	return rand.Intn(9)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
 type Button struct {
 	X, Y, W, H int
 	str        string
+	move       rps.Move
+	keys       []ebiten.Key // keys that press it too
+	underline  int          // index in str of the letter to underline as its key, -1 for none
+}
+
+// Hit reports whether the point is on the button.
+func (b Button) Hit(x_pos, y_pos int) bool {
+
+	return x_pos >= b.X && x_pos <= b.X+b.W && y_pos >= b.Y && y_pos <= b.Y+b.H
+}
+
+// Hovered reports whether the mouse is over the button.
+func (b Button) Hovered() bool {
+
+	return b.Hit(ebiten.CursorPosition())
+}
+
+// Held reports whether the button is being pressed, by the mouse or by
+// one of its keys.
+func (b Button) Held() bool {
+
+	if b.Hovered() && ebiten.IsMouseButtonPressed(ebiten.MouseButton0) {
+		return true
+	}
+
+	for _, key := range b.keys {
+		if ebiten.IsKeyPressed(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KeyPressed reports whether one of the button's keys was just pressed.
+func (b Button) KeyPressed() bool {
+
+	for _, key := range b.keys {
+		if inpututil.IsKeyJustPressed(key) {
+			return true
+		}
+	}
+
+	return false
 }
 
 type Game struct {
@@ -66,38 +137,150 @@ type Game struct {
 	user_choice string
 	comp_choice string
 	result      string
-	player_wins int
-	comp_wins   int
-	draws       int
+	outcome     string
+	state       int
+	reveal      *reveal // the hands being thrown, nil between rounds
+
+	// WHO'S PLAYING
+	mode        int
+	mode_button Button
+	first_pick  *rps.Move // the hidden first pick of a hotseat round
+	online      online
+
+	// THE MATCH BEING PLAYED, AND HOW THE PLAYER HAS DONE
+	match          *rps.Match
+	match_score    [2]int // matches won by each side since the mode was picked
+	best_of        int
+	best_of_button Button
+	stats_button   Button
+	stats          *Stats
+	session        Record
+	win_rates      []float64 // session win rate after every round
+
+	// THE COMPUTER
+	strategy      rps.Strategy
+	strategy_name string
+	ai_button     Button
+	rng           *rand.Rand
+	seed          int64           // where rng started, to play the session again
+	fair          *rps.Transcript // the sealed seed and the rounds so far, nil unless playing fair
+	fair_end      *fair_end
+
+	// THE RULES, AND WHERE THEIR BUTTONS LEFT ROOM FOR THE TEXT
+	rules        *rps.Rules
+	rule_sets    []*rps.Rules
+	rules_index  int
+	rules_button Button
+	text_top     int
+	text_gap     int
 }
 
 func main() {
 
+	ai := flag.String("ai", "meta", "the computer's strategy: "+strings.Join(rps.Adaptive(), ", ")+" or random")
+	rules_path := flag.String("rules", "", "rules file to start with, the first in "+rulesDir+"/ if empty")
+	host := flag.String("host", "", "host an online session on this address, such as :4040")
+	join := flag.String("join", "", "join an online session at this address, such as 127.0.0.1:4040")
+	seed := flag.Int64("seed", 0, "seed for the computer, to play a session again, picked from the clock if 0")
+	fair := flag.Bool("fair", false, "seal the computer's seed before the session and reveal it after, so every throw can be checked")
+	flag.Parse()
+
+	switch {
+	case *fair && *seed != 0:
+		log.Fatal("-fair seals a seed of its own, it can't be given one")
+	case *fair && (*host != "" || *join != ""):
+		log.Fatal("-fair is for playing the computer, not online")
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
 	ebiten.SetWindowTitle("Rock, Paper, Scissors - Second")
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 
-	buttons := []Button{}
+	rule_sets := loadRules(rulesDir)
+	rules_index := 0
 
-	labels := []string{"Rock", "Paper", "Scissors"}
+	if *rules_path != "" {
 
-	for i := 0; i < 3; i++ {
-		buttons = append(buttons, Button{
-			X:   (10 + ((buttonWidth + 10) * i)),
-			Y:   40,
-			W:   buttonWidth,
-			H:   buttonHeight,
-			str: labels[i],
-		})
+		var err error
+		rule_sets, rules_index, err = useRules(rule_sets, *rules_path)
+
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	game := &Game{
-		Buttons:     buttons,
 		user_choice: "",
 		comp_choice: "",
 		result:      "",
-		player_wins: 0,
-		comp_wins:   0,
-		draws:       0,
+		state:       stateGame,
+		mode_button: Button{
+			X: 270,
+			Y: 4,
+			W: 120,
+			H: 30,
+		},
+		online:  online{host_addr: *host, join_addr: *join},
+		best_of: best_of_choices[0],
+		best_of_button: Button{
+			X: 400,
+			Y: 4,
+			W: 120,
+			H: 30,
+		},
+		stats_button: Button{
+			X:   530,
+			Y:   4,
+			W:   100,
+			H:   30,
+			str: "Stats",
+		},
+		stats:   LoadStats(statsPath()),
+		session: newRecord(),
+		ai_button: Button{
+			X: (10 + ((buttonWidth + 10) * 2)),
+			Y: 420,
+			W: buttonWidth,
+			H: buttonHeight,
+		},
+		rules_button: Button{
+			X: 10,
+			Y: 420,
+			W: buttonWidth*2 + 10,
+			H: buttonHeight,
+		},
+		rng:       rand.New(rand.NewSource(*seed)),
+		seed:      *seed,
+		rule_sets: rule_sets,
+		rules:     rule_sets[rules_index],
+	}
+
+	if err := game.SetStrategy(*ai); err != nil {
+		log.Fatal(err)
+	}
+
+	game.SetRules(rules_index)
+	game.SetBestOf(game.best_of)
+	game.SetMode(modeComputer)
+
+	// A SEALED SEED IS REVEALED WHEN THE WINDOW CLOSES, IF NOT BEFORE
+	if *fair {
+
+		if err := game.StartFair(); err != nil {
+			log.Fatal(err)
+		}
+
+		ebiten.SetWindowClosingHandled(true)
+
+	} else {
+		log.Printf("session seed %v, play it again with -seed %v", *seed, *seed)
+	}
+
+	if *host != "" || *join != "" {
+		game.StartOnline()
 	}
 
 	err := ebiten.RunGame(game)
@@ -109,91 +292,325 @@ func main() {
 
 func (g *Game) Update() error {
 
-	g.ButtonPressed()
+	if ebiten.IsWindowBeingClosed() {
+
+		if g.fair != nil {
+			g.EndFair()
+		}
+
+		return ebiten.Termination
+	}
+
+	switch g.state {
+
+	case stateGame:
+		if g.reveal != nil {
+			g.UpdateReveal()
+		}
+
+		g.ButtonPressed()
+
+		if g.mode == modeOnline {
+			g.UpdateOnline()
+		}
+
+	case stateStats:
+		g.UpdateStats()
+
+	case stateConnecting:
+		g.UpdateConnecting()
+
+	case stateFairEnd:
+		g.UpdateFairEnd()
+	}
 
 	return nil
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
 
+	switch g.state {
+
+	case stateStats:
+		g.DrawStats(screen)
+		return
+
+	case stateConnecting:
+		g.DrawConnecting(screen)
+		return
+
+	case stateFairEnd:
+		g.DrawFairEnd(screen)
+		return
+	}
+
+	names := side_names[g.mode]
+
 	// DRAWING BUTTONS
 	for _, button := range g.Buttons {
+		g.DrawMoveButton(screen, button)
+	}
 
-		vector.DrawFilledRect(screen, float32(button.X), float32(button.Y), float32(buttonWidth), float32(buttonHeight), color.White, false)
+	user_str := fmt.Sprintf("%v's Choice: %v", names[0], g.user_choice)
+	comp_str := fmt.Sprintf("%v's Choice: %v", names[1], g.comp_choice)
+	res := fmt.Sprintf("Result: %v", g.result)
 
-		text.Draw(screen, button.str, game_font, button.X+20, button.Y+25, color.Black)
+	lines := []string{
+		user_str,
+		comp_str,
+		g.outcome,
+		res,
+		fmt.Sprintf("Match: %v %v - %v %v", names[0], g.match.Wins, g.match.Losses, names[1]),
+		fmt.Sprintf("Draws: %v", g.match.Draws),
+		fmt.Sprintf("Matches Won: %v - %v", g.match_score[0], g.match_score[1]),
 	}
 
-	user_str := fmt.Sprintf("User's Choice: %v", g.user_choice)
-	comp_str := fmt.Sprintf("Comp's Choice: %v", g.comp_choice)
-	res := fmt.Sprintf("Result: %v", g.result)
+	// THE HANDS TAKE THE TEXT'S PLACE UNTIL THE ROUND IS PLAYED
+	if g.reveal != nil {
+		g.DrawReveal(screen)
+		lines = nil
+	}
+
+	for i, line := range lines {
+		text.Draw(screen, line, game_font, 10, g.text_top+i*g.text_gap, color.White)
+	}
+
+	text.Draw(screen, fmt.Sprintf("First to %v wins", g.match.Needed()), small_font, 10, 26, color.White)
 
-	text.Draw(screen, user_str, game_font, 10, 125, color.White)
-	text.Draw(screen, comp_str, game_font, 10, 175, color.White)
-	text.Draw(screen, res, game_font, 10, 225, color.White)
+	// RULES, MODE, MATCH LENGTH AND STATS BUTTONS, AND THE STRATEGY WHEN
+	// THERE'S A COMPUTER TO HAVE ONE
+	buttons := []Button{g.rules_button, g.mode_button, g.best_of_button, g.stats_button}
 
-	text.Draw(screen, fmt.Sprintf("Player Wins: %v", g.player_wins), game_font, 10, 275, color.White)
-	text.Draw(screen, fmt.Sprintf("Comp Wins: %v", g.comp_wins), game_font, 10, 325, color.White)
-	text.Draw(screen, fmt.Sprintf("Draws: %v", g.draws), game_font, 10, 375, color.White)
+	if g.mode == modeComputer {
+		buttons = append(buttons, g.ai_button)
+	}
+
+	for _, button := range buttons {
+		g.DrawButton(screen, button)
+	}
+
+	if g.fair != nil {
+		text.Draw(screen, "Seed hash "+g.fair.Hash[:40]+"...", small_font, 10, 476, stats_label)
+	}
+
+	if g.match.Over() {
+		g.DrawMatchOver(screen)
+	}
+}
+
+// DrawMoveButton draws the button for a move, lighter under the mouse and
+// darker and lower while pressed. Moves can't be picked during a reveal,
+// so the buttons grey out.
+func (g *Game) DrawMoveButton(screen *ebiten.Image, button Button) {
+
+	clr := color.RGBA{255, 255, 255, 255}
+	y := button.Y
+
+	switch {
+
+	case g.reveal != nil:
+		clr = color.RGBA{130, 130, 130, 255}
+
+	case button.Held():
+		clr = color.RGBA{160, 200, 200, 255}
+		y += 2
+
+	case button.Hovered():
+		clr = color.RGBA{215, 235, 235, 255}
+	}
+
+	vector.DrawFilledRect(screen, float32(button.X), float32(y), float32(button.W), float32(button.H), clr, false)
+
+	// CENTRED, THE BUTTONS NARROW WHEN THERE ARE MORE MOVES
+	str_width := font.MeasureString(game_font, button.str).Ceil()
+	x := button.X + (button.W-str_width)/2
+
+	text.Draw(screen, button.str, game_font, x, y+25, color.Black)
+
+	// UNDERLINE THE LETTER THAT PICKS THE MOVE FROM THE KEYBOARD
+	if i := button.underline; i >= 0 {
+		from := x + font.MeasureString(game_font, button.str[:i]).Ceil()
+		width := font.MeasureString(game_font, button.str[i:i+1]).Ceil()
+		vector.DrawFilledRect(screen, float32(from), float32(y+29), float32(width), 2, color.Black, false)
+	}
+}
+
+// DrawButton draws one of the smaller buttons that aren't moves.
+func (g *Game) DrawButton(screen *ebiten.Image, button Button) {
+
+	clr := color.RGBA{150, 200, 200, 255}
+
+	switch {
+
+	case button.Held():
+		clr = color.RGBA{110, 165, 165, 255}
+
+	case button.Hovered():
+		clr = color.RGBA{185, 225, 225, 255}
+	}
+
+	vector.DrawFilledRect(screen, float32(button.X), float32(button.Y), float32(button.W), float32(button.H), clr, false)
+	text.Draw(screen, button.str, small_font, button.X+12, button.Y+button.H/2+6, color.Black)
+}
+
+// DrawMatchOver announces who took the match over the text.
+func (g *Game) DrawMatchOver(screen *ebiten.Image) {
+
+	names := side_names[g.mode]
+
+	banner := names[0] + " Wins The Match!"
+	clr := color.RGBA{80, 200, 120, 255}
+
+	if !g.match.Won() {
+		banner = names[1] + " Wins The Match!"
+		clr = color.RGBA{230, 90, 90, 255}
+	}
+
+	score := fmt.Sprintf("%v - %v, best of %v", g.match.Wins, g.match.Losses, g.match.BestOf)
+
+	y := float32(g.text_top + 3*g.text_gap - 40)
+
+	vector.DrawFilledRect(screen, 40, y, screenWidth-80, 110, color.RGBA{20, 20, 30, 240}, false)
+	vector.StrokeRect(screen, 40, y, screenWidth-80, 110, 3, clr, false)
+
+	for i, line := range []string{banner, score} {
+		face := game_font
+		if i > 0 {
+			face = small_font
+		}
 
+		width := font.MeasureString(face, line).Ceil()
+		text.Draw(screen, line, face, (screenWidth-width)/2, int(y)+40+i*30, clr)
+	}
+
+	hint := "Click to play another match"
+	width := font.MeasureString(small_font, hint).Ceil()
+	text.Draw(screen, hint, small_font, (screenWidth-width)/2, int(y)+96, color.White)
+}
+
+// NewMatch starts a match of the current length. A match still going is
+// dropped without being counted.
+func (g *Game) NewMatch() {
+
+	g.match = rps.NewMatch(g.best_of)
+	g.reveal = nil
+	g.user_choice, g.comp_choice, g.result, g.outcome = "", "", "", ""
+}
+
+// SetBestOf changes how long the matches are, starting a new one.
+func (g *Game) SetBestOf(best_of int) {
+
+	g.best_of = best_of
+	g.best_of_button.str = fmt.Sprintf("Best of %v", best_of)
+
+	g.NewMatch()
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return screenWidth, screenHeight
 }
 
-func (g *Game) GenerateCompChoice() {
+// SetStrategy gives the computer a fresh strategy called name. Whatever
+// the old one had learned about the player is forgotten.
+func (g *Game) SetStrategy(name string) error {
 
-	choices := []string{"Rock", "Paper", "Scissors", "Rock", "Scissors", "Paper", "Paper", "Rock", "Scissors"}
+	strategy, err := rps.New(name, g.rules, g.rng)
 
-	g.comp_choice = choices[generateRandom()]
+	if err != nil {
+		return err
+	}
+
+	g.strategy = strategy
+	g.strategy_name = name
+	g.ai_button.str = "AI: " + name
+
+	return nil
+}
+
+// NextStrategy moves the computer on to the next strategy, going through
+// random and then the adaptive ones.
+func (g *Game) NextStrategy() {
+
+	names := append([]string{"random"}, rps.Adaptive()...)
+	next := (slices.Index(names, g.strategy_name) + 1) % len(names)
+
+	if err := g.SetStrategy(names[next]); err != nil {
+		log.Print(err)
+	}
+}
+
+func (g *Game) GenerateCompChoice() {
+
+	g.comp_choice = g.rules.MoveName(g.strategy.Throw())
 }
 
 func (g *Game) ButtonPressed() {
 
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButton0) {
+	// NOTHING CAN BE PRESSED WHILE A ROUND IS BEING REVEALED
+	if g.reveal != nil {
+		return
+	}
 
-		x_pos, y_pos := ebiten.CursorPosition()
+	// A MOVE'S KEYS PICK IT, OR START THE NEXT MATCH ONCE ONE IS OVER
+	for _, button := range g.Buttons {
 
-		for _, button := range g.Buttons {
+		if !button.KeyPressed() {
+			continue
+		}
 
-			if x_pos >= button.X && x_pos <= button.X+buttonWidth {
-				if y_pos >= button.Y && y_pos <= button.Y+buttonHeight {
+		if g.match.Over() {
+			g.NewMatch()
+		} else {
+			g.Pick(button.move)
+		}
 
-					g.user_choice = button.str
-					g.GenerateCompChoice()
+		return
+	}
 
-					dicts := map[string]string{
-						"Rock":     "Paper",
-						"Paper":    "Scissors",
-						"Scissors": "Rock",
-					}
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButton0) {
+
+		x_pos, y_pos := ebiten.CursorPosition()
 
-					comp_won := false
+		// ONLINE, THE HOST'S RULES AND MATCH LENGTH STAND
+		offline := g.mode != modeOnline
 
-					if g.user_choice == g.comp_choice {
-						g.result = "It's a Draw!"
-						g.draws++
+		switch {
 
-					} else {
+		// PLAYING FAIR, THE AI BUTTON REVEALS THE SEED INSTEAD
+		case g.mode == modeComputer && g.fair != nil && g.ai_button.Hit(x_pos, y_pos):
+			g.EndFair()
+			return
 
-						for k, v := range dicts {
+		case g.mode == modeComputer && g.ai_button.Hit(x_pos, y_pos):
+			g.NextStrategy()
+			return
 
-							if k == g.user_choice && v == g.comp_choice {
-								g.result = "Comp Wins!"
-								comp_won = true
-								g.comp_wins++
+		// THE RULES ARE SEALED IN WITH A FAIR SEED
+		case offline && g.fair == nil && g.rules_button.Hit(x_pos, y_pos):
+			g.NextRules()
+			return
 
-							}
-						}
+		case offline && g.mode_button.Hit(x_pos, y_pos):
+			g.NextMode()
+			return
 
-						if !comp_won {
-							g.result = "Player Wins!"
-							g.player_wins++
-						}
-					}
+		case offline && g.best_of_button.Hit(x_pos, y_pos):
+			next := (slices.Index(best_of_choices, g.best_of) + 1) % len(best_of_choices)
+			g.SetBestOf(best_of_choices[next])
+			return
 
-				}
+		case g.stats_button.Hit(x_pos, y_pos):
+			g.state = stateStats
+			return
+
+		case g.match.Over():
+			g.NewMatch()
+			return
+		}
+
+		for _, button := range g.Buttons {
+			if button.Hit(x_pos, y_pos) {
+				g.Pick(button.move)
 			}
 		}
 	}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+
+	"Rock-Paper-Scissors/rps"
+)
+
+// MODES
+// Who's on the other side: the computer, a second player at the same
+// screen, or one on another machine.
+const (
+	modeComputer = iota
+	modeHotseat
+	modeOnline
+)
+
+// what the two sides are called in each mode, the one at the mouse first
+var side_names = [][2]string{
+	modeComputer: {"Player", "Comp"},
+	modeHotseat:  {"Player 1", "Player 2"},
+	modeOnline:   {"Player", "Opponent"},
+}
+
+var mode_labels = []string{
+	modeComputer: "Vs Computer",
+	modeHotseat:  "Hotseat",
+	modeOnline:   "Online",
+}
+
+// SetMode switches who the player is up against and starts a new match.
+func (g *Game) SetMode(mode int) {
+
+	g.mode = mode
+	g.mode_button.str = mode_labels[mode]
+	g.match_score = [2]int{}
+	g.first_pick = nil
+
+	g.NewMatch()
+}
+
+// NextMode swaps between the computer and hotseat. Online is only ever
+// reached from the command line.
+func (g *Game) NextMode() {
+
+	if g.mode == modeComputer {
+		g.SetMode(modeHotseat)
+	} else {
+		g.SetMode(modeComputer)
+	}
+}
+
+// Pick is the player at the mouse choosing a move.
+func (g *Game) Pick(move rps.Move) {
+
+	switch g.mode {
+
+	case modeComputer:
+		g.GenerateCompChoice()
+		comp_move, _ := g.rules.Find(g.comp_choice)
+
+		// LET THE COMPUTER LEARN FROM THE ROUND
+		g.strategy.Observe(comp_move, move)
+
+		if g.fair != nil {
+			g.fair.Add(g.rules, move, comp_move)
+		}
+
+		g.Reveal(move, comp_move)
+
+	case modeHotseat:
+		// THE FIRST PICK STAYS HIDDEN UNTIL THE SECOND IS IN
+		if g.first_pick == nil {
+			g.first_pick = &move
+			g.user_choice, g.comp_choice, g.outcome = "(hidden)", "", ""
+			g.result = "Player 2, your pick"
+			return
+		}
+
+		first := *g.first_pick
+		g.first_pick = nil
+		g.Reveal(first, move)
+
+	case modeOnline:
+		g.PickOnline(move)
+	}
+}
+
+// PlayRound settles a round between the two sides' moves.
+func (g *Game) PlayRound(mine, theirs rps.Move) {
+
+	names := side_names[g.mode]
+
+	g.user_choice = g.rules.MoveName(mine)
+	g.comp_choice = g.rules.MoveName(theirs)
+	g.outcome = g.rules.Describe(mine, theirs)
+
+	outcome := g.rules.Judge(mine, theirs)
+
+	switch outcome {
+
+	case rps.Draw:
+		g.result = "It's a Draw!"
+		g.outcome = ""
+
+	case rps.Loss:
+		g.result = fmt.Sprintf("%v Wins!", names[1])
+
+	case rps.Win:
+		g.result = fmt.Sprintf("%v Wins!", names[0])
+	}
+
+	g.match.Play(outcome)
+
+	if g.match.Over() {
+		if g.match.Won() {
+			g.match_score[0]++
+		} else {
+			g.match_score[1]++
+		}
+	}
+
+	// ONLY ROUNDS AGAINST THE COMPUTER GO IN THE STATS
+	if g.mode == modeComputer {
+		g.RecordRound(g.user_choice, outcome)
+	}
+}
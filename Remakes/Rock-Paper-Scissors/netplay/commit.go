@@ -0,0 +1,86 @@
+package netplay
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// nonceSize is how many random bytes hide a move in its commitment. With
+// only a handful of moves to try, the hash alone would give them away.
+const nonceSize = 32
+
+// ROLES
+// Every commitment names the side that made it, so one side can't send
+// back the other's commitment and reveal as its own and force a draw.
+const (
+	RoleHost   = "host"
+	RoleJoiner = "joiner"
+)
+
+// ErrCheated means the other player revealed something other than what
+// they committed to.
+var ErrCheated = errors.New("netplay: the other player's move doesn't match their commitment")
+
+// COMMIT AND REVEAL
+// Each player sends a Commitment to their move first, and only once both
+// are in do they Reveal. The commitment says nothing about the move, so
+// waiting for the other one gains nothing, and it binds the move, so it
+// can't be changed after seeing theirs.
+type Commitment struct {
+	Round int    `json:"round"`
+	Hash  string `json:"hash"` // hex SHA-256 of the role, the round, the move and the nonce
+}
+
+// Reveal opens a Commitment once both players have committed.
+type Reveal struct {
+	Role  string `json:"role"`
+	Round int    `json:"round"`
+	Move  string `json:"move"`
+	Nonce string `json:"nonce"` // hex
+}
+
+// Commit makes a commitment to move in round for the side playing role,
+// and the reveal that opens it later.
+func Commit(role string, round int, move string) (Commitment, Reveal, error) {
+
+	nonce := make([]byte, nonceSize)
+
+	if _, err := rand.Read(nonce); err != nil {
+		return Commitment{}, Reveal{}, err
+	}
+
+	r := Reveal{Role: role, Round: round, Move: move, Nonce: hex.EncodeToString(nonce)}
+
+	return Commitment{Round: round, Hash: r.hash()}, r, nil
+}
+
+func (r Reveal) hash() string {
+
+	sum := sha256.Sum256(fmt.Appendf(nil, "rps:%s:%d:%s:%s", r.Role, r.Round, r.Move, r.Nonce))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify checks that r opens c, and that both came from the side playing
+// role.
+func Verify(c Commitment, r Reveal, role string) error {
+
+	nonce, err := hex.DecodeString(r.Nonce)
+
+	switch {
+	case err != nil || len(nonce) != nonceSize:
+		return fmt.Errorf("%w: bad nonce", ErrCheated)
+	case r.Role != role:
+		return fmt.Errorf("%w: a reveal for the %v, not the %v", ErrCheated, r.Role, role)
+	case c.Round != r.Round:
+		return fmt.Errorf("%w: reveal of round %v for round %v", ErrCheated, r.Round, c.Round)
+	case subtle.ConstantTimeCompare([]byte(c.Hash), []byte(r.hash())) != 1:
+		return ErrCheated
+	}
+
+	return nil
+}
@@ -0,0 +1,308 @@
+// Package netplay plays Rock-Paper-Scissors between two machines. Neither
+// side can cheat by waiting to see the other's move: every round both send
+// a commitment to their move first and only reveal it once the other's
+// commitment is in.
+package netplay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"Rock-Paper-Scissors/rps"
+)
+
+// Version is bumped whenever the protocol changes.
+const Version = 1
+
+// handshakeTimeout bounds each step of connecting.
+const handshakeTimeout = 5 * time.Second
+
+// ErrDisconnected means the other player hung up.
+var ErrDisconnected = errors.New("netplay: the other player left")
+
+// MESSAGE KINDS
+const (
+	KindHello  = "hello"  // host to joiner, then echoed back
+	KindCommit = "commit" // the sender's commitment for a round
+	KindReveal = "reveal" // the sender's move and nonce for a round
+	KindBye    = "bye"    // the sender is leaving
+)
+
+// Hello is the handshake. The host picks the rules and match length and
+// the joiner plays them.
+type Hello struct {
+	Version int    `json:"version"`
+	Rules   string `json:"rules"` // in the rules file format
+	BestOf  int    `json:"best_of"`
+}
+
+// Message is one line of the protocol, JSON encoded. Which fields mean
+// anything depends on Kind.
+type Message struct {
+	Kind   string      `json:"kind"`
+	Hello  *Hello      `json:"hello,omitempty"`
+	Commit *Commitment `json:"commit,omitempty"`
+	Reveal *Reveal     `json:"reveal,omitempty"`
+}
+
+// CONNECTION
+// Conn sends and receives messages over a network connection.
+type Conn struct {
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+	mu   sync.Mutex // a bye can be sent while a round is being played
+}
+
+// NewConn speaks the protocol over conn.
+func NewConn(conn net.Conn) *Conn {
+
+	return &Conn{conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)}
+}
+
+func (c *Conn) Send(m Message) error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.enc.Encode(m)
+}
+
+// Recv blocks until a message arrives. A bye, or the connection closing,
+// is ErrDisconnected.
+func (c *Conn) Recv() (Message, error) {
+
+	m := Message{}
+
+	if err := c.dec.Decode(&m); err != nil {
+		if errors.Is(err, net.ErrClosed) || errors.Is(err, io.EOF) {
+			return m, ErrDisconnected
+		}
+		return m, err
+	}
+
+	if m.Kind == KindBye {
+		return m, ErrDisconnected
+	}
+
+	return m, nil
+}
+
+// expect receives the next message and fails unless it's of kind.
+func (c *Conn) expect(kind string) (Message, error) {
+
+	m, err := c.Recv()
+
+	if err == nil && m.Kind != kind {
+		err = fmt.Errorf("netplay: expected %v, got %v", kind, m.Kind)
+	}
+
+	return m, err
+}
+
+func (c *Conn) Close() error {
+
+	return c.conn.Close()
+}
+
+// PEER
+// Peer is one side of an online session.
+type Peer struct {
+	Rules  *rps.Rules
+	BestOf int
+
+	conn  *Conn
+	role  string // RoleHost or RoleJoiner
+	round int
+}
+
+// Host waits on ln for one player to join and offers them a session.
+func Host(ln net.Listener, rules *rps.Rules, bestOf int) (*Peer, error) {
+
+	conn, err := ln.Accept()
+
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := rules.MarshalText()
+
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c := NewConn(conn)
+	hello := Hello{Version: Version, Rules: string(text), BestOf: bestOf}
+
+	if err := handshake(conn, func() error {
+
+		if err := c.Send(Message{Kind: KindHello, Hello: &hello}); err != nil {
+			return err
+		}
+
+		reply, err := c.expect(KindHello)
+
+		if err != nil {
+			return err
+		}
+
+		if reply.Hello == nil || *reply.Hello != hello {
+			return errors.New("netplay: player didn't accept the session")
+		}
+
+		return nil
+	}); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return &Peer{Rules: rules, BestOf: bestOf, conn: c, role: RoleHost}, nil
+}
+
+// Join connects to a host at addr and plays by whatever it offers.
+func Join(addr string) (*Peer, error) {
+
+	conn, err := net.DialTimeout("tcp", addr, handshakeTimeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewConn(conn)
+	p := &Peer{conn: c, role: RoleJoiner}
+
+	if err := handshake(conn, func() error {
+
+		m, err := c.expect(KindHello)
+
+		if err != nil {
+			return err
+		}
+
+		if m.Hello == nil || m.Hello.Version != Version {
+			return fmt.Errorf("netplay: host speaks another version, we speak %v", Version)
+		}
+
+		p.Rules, err = rps.ParseRules("host", []byte(m.Hello.Rules))
+
+		if err != nil {
+			return err
+		}
+
+		p.BestOf = m.Hello.BestOf
+
+		// ECHO IT BACK TO AGREE
+		return c.Send(m)
+	}); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// handshake runs shake with a deadline on conn, cleared afterwards.
+func handshake(conn net.Conn, shake func() error) error {
+
+	conn.SetDeadline(time.Now().Add(handshakeTimeout))
+
+	if err := shake(); err != nil {
+		return err
+	}
+
+	return conn.SetDeadline(time.Time{})
+}
+
+// Play throws mine in the next round and returns the other player's move
+// once it's been checked against their commitment. It blocks until they
+// pick, so it wants its own goroutine in a game. Once it fails the session
+// is over.
+func (p *Peer) Play(mine rps.Move) (rps.Move, error) {
+
+	p.round++
+
+	commit, reveal, err := Commit(p.role, p.round, p.Rules.MoveName(mine))
+
+	if err != nil {
+		return 0, err
+	}
+
+	// COMMIT, AND WAIT FOR THEIRS BEFORE GIVING ANYTHING AWAY
+	if err := p.conn.Send(Message{Kind: KindCommit, Commit: &commit}); err != nil {
+		return 0, err
+	}
+
+	m, err := p.conn.expect(KindCommit)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if m.Commit == nil || m.Commit.Round != p.round {
+		return 0, fmt.Errorf("netplay: commitment for the wrong round")
+	}
+
+	theirCommit := *m.Commit
+
+	// OURS SENT BACK AS THEIRS, TO SEND BACK OUR REVEAL TOO
+	if theirCommit == commit {
+		return 0, fmt.Errorf("%w: they sent our own commitment back", ErrCheated)
+	}
+
+	// NOW BOTH MOVES ARE FIXED, SHOW THEM
+	if err := p.conn.Send(Message{Kind: KindReveal, Reveal: &reveal}); err != nil {
+		return 0, err
+	}
+
+	m, err = p.conn.expect(KindReveal)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if m.Reveal == nil {
+		return 0, ErrCheated
+	}
+
+	if err := Verify(theirCommit, *m.Reveal, p.other()); err != nil {
+		return 0, err
+	}
+
+	theirs, ok := p.Rules.Find(m.Reveal.Move)
+
+	if !ok {
+		return 0, fmt.Errorf("%w: %q isn't a move", ErrCheated, m.Reveal.Move)
+	}
+
+	return theirs, nil
+}
+
+// other is the role of the other player.
+func (p *Peer) other() string {
+
+	if p.role == RoleHost {
+		return RoleJoiner
+	}
+
+	return RoleHost
+}
+
+// Round is how many rounds have been played.
+func (p *Peer) Round() int {
+
+	return p.round
+}
+
+// Close tells the other player we're leaving and hangs up.
+func (p *Peer) Close() error {
+
+	p.conn.Send(Message{Kind: KindBye})
+
+	return p.conn.Close()
+}
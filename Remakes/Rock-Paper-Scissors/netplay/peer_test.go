@@ -0,0 +1,274 @@
+package netplay_test
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"testing"
+
+	"Rock-Paper-Scissors/netplay"
+	"Rock-Paper-Scissors/rps"
+)
+
+func loadRules(t *testing.T) *rps.Rules {
+
+	rules, err := rps.LoadRules("../rules/2-lizard-spock.txt")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rules
+}
+
+// connect hosts on a free loopback port, joins it, and returns both ends.
+func connect(t *testing.T, rules *rps.Rules) (host, joiner *netplay.Peer) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ln.Close()
+
+	hosted := make(chan error, 1)
+
+	go func() {
+		host, err = netplay.Host(ln, rules, 5)
+		hosted <- err
+	}()
+
+	joiner, joinErr := netplay.Join(ln.Addr().String())
+
+	if err := errors.Join(<-hosted, joinErr); err != nil {
+		t.Fatal(err)
+	}
+
+	if joiner.Rules.Name != rules.Name || joiner.BestOf != 5 {
+		t.Fatalf("joiner plays %v best of %v, host offered %v best of 5", joiner.Rules.Name, joiner.BestOf, rules.Name)
+	}
+
+	return host, joiner
+}
+
+// TestSession plays an honest session between two strategies and fails if
+// the two sides ever disagree about a round.
+func TestSession(t *testing.T) {
+
+	rounds := 300
+	if testing.Short() {
+		rounds = 50
+	}
+
+	rules := loadRules(t)
+	host, joiner := connect(t, rules)
+
+	defer host.Close()
+	defer joiner.Close()
+
+	a, _ := rps.New("meta", rules, rand.New(rand.NewSource(1)))
+	b, _ := rps.New("frequency", joiner.Rules, rand.New(rand.NewSource(2)))
+
+	for round := 1; round <= rounds; round++ {
+
+		hostMove, joinMove := a.Throw(), b.Throw()
+
+		type result struct {
+			theirs rps.Move
+			err    error
+		}
+
+		joined := make(chan result, 1)
+
+		go func() {
+			theirs, err := joiner.Play(joinMove)
+			joined <- result{theirs, err}
+		}()
+
+		hostSaw, err := host.Play(hostMove)
+		j := <-joined
+
+		if err := errors.Join(err, j.err); err != nil {
+			t.Fatalf("round %v: %v", round, err)
+		}
+
+		if hostSaw != joinMove || j.theirs != hostMove {
+			t.Fatalf("round %v: host threw %v and saw %v, joiner threw %v and saw %v",
+				round, rules.MoveName(hostMove), rules.MoveName(hostSaw), rules.MoveName(joinMove), rules.MoveName(j.theirs))
+		}
+
+		a.Observe(hostMove, hostSaw)
+		b.Observe(joinMove, j.theirs)
+	}
+}
+
+// TestCheaters plays the host against joiners that break the protocol in
+// one way or another, every one of which the host has to catch.
+func TestCheaters(t *testing.T) {
+
+	rules := loadRules(t)
+
+	tests := []struct {
+		name  string
+		cheat func(c *netplay.Conn) error
+	}{
+		{"changed move", func(c *netplay.Conn) error {
+
+			// COMMIT TO THE FIRST MOVE, THEN CLAIM THE SECOND
+			commit, reveal, _ := netplay.Commit(netplay.RoleJoiner, 1, rules.Moves[0])
+			reveal.Move = rules.Moves[1]
+
+			c.Send(netplay.Message{Kind: netplay.KindCommit, Commit: &commit})
+			c.Recv()
+			c.Send(netplay.Message{Kind: netplay.KindReveal, Reveal: &reveal})
+
+			return nil
+		}},
+
+		{"mirrored commitment", func(c *netplay.Conn) error {
+
+			// THE HOST'S COMMITMENT BACK AS OURS, THEN ITS REVEAL, FOR A DRAW
+			m, err := c.Recv()
+
+			if err != nil {
+				return err
+			}
+
+			c.Send(m)
+
+			if m, err = c.Recv(); err == nil && m.Kind == netplay.KindReveal {
+				c.Send(m)
+				return errors.New("the host revealed its move to its own commitment")
+			}
+
+			return nil
+		}},
+
+		{"reveal as the host", func(c *netplay.Conn) error {
+
+			commit, reveal, _ := netplay.Commit(netplay.RoleHost, 1, rules.Moves[0])
+
+			c.Send(netplay.Message{Kind: netplay.KindCommit, Commit: &commit})
+			c.Recv()
+			c.Send(netplay.Message{Kind: netplay.KindReveal, Reveal: &reveal})
+
+			return nil
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer ln.Close()
+
+			cheated := make(chan error, 1)
+
+			go func() {
+
+				conn, err := net.Dial("tcp", ln.Addr().String())
+
+				if err != nil {
+					cheated <- err
+					return
+				}
+
+				c := netplay.NewConn(conn)
+				defer c.Close()
+
+				hello, err := c.Recv()
+
+				if err != nil {
+					cheated <- err
+					return
+				}
+
+				c.Send(hello)
+				cheated <- test.cheat(c)
+			}()
+
+			host, err := netplay.Host(ln, rules, 3)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = host.Play(0)
+			host.Close()
+
+			if !errors.Is(err, netplay.ErrCheated) {
+				t.Errorf("host got %v, not %v", err, netplay.ErrCheated)
+			}
+
+			if err := <-cheated; err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+
+	commit, reveal, err := netplay.Commit(netplay.RoleHost, 3, "Rock")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	change := func(f func(r *netplay.Reveal)) netplay.Reveal {
+		r := reveal
+		f(&r)
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		reveal netplay.Reveal
+		role   string
+		ok     bool
+	}{
+		{"honest", reveal, netplay.RoleHost, true},
+		{"changed move", change(func(r *netplay.Reveal) { r.Move = "Paper" }), netplay.RoleHost, false},
+		{"changed round", change(func(r *netplay.Reveal) { r.Round = 4 }), netplay.RoleHost, false},
+		{"short nonce", change(func(r *netplay.Reveal) { r.Nonce = r.Nonce[:10] }), netplay.RoleHost, false},
+
+		// THE HOST'S COMMITMENT AND REVEAL, PASSED OFF BY THE JOINER
+		{"mirrored", reveal, netplay.RoleJoiner, false},
+		{"mirrored, role changed", change(func(r *netplay.Reveal) { r.Role = netplay.RoleJoiner }), netplay.RoleJoiner, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			err := netplay.Verify(commit, test.reveal, test.role)
+
+			if test.ok && err != nil {
+				t.Errorf("honest reveal refused: %v", err)
+			}
+
+			if !test.ok && !errors.Is(err, netplay.ErrCheated) {
+				t.Errorf("got %v, not %v", err, netplay.ErrCheated)
+			}
+		})
+	}
+}
+
+// TestHangUp leaves half way through a round, which the other side has to
+// notice rather than wait forever.
+func TestHangUp(t *testing.T) {
+
+	host, joiner := connect(t, loadRules(t))
+	defer host.Close()
+
+	joiner.Close()
+
+	if _, err := host.Play(0); !errors.Is(err, netplay.ErrDisconnected) {
+		t.Errorf("host got %v, not %v", err, netplay.ErrDisconnected)
+	}
+}
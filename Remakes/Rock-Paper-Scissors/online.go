@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"net"
+	"slices"
+
+	"Rock-Paper-Scissors/netplay"
+	"Rock-Paper-Scissors/rps"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+)
+
+// connection is the outcome of hosting or joining.
+type connection struct {
+	peer *netplay.Peer
+	err  error
+}
+
+// round_result is the other player's move once it's been revealed.
+type round_result struct {
+	mine, theirs rps.Move
+	err          error
+}
+
+// ONLINE PLAY, SET UP FROM THE COMMAND LINE
+type online struct {
+	host_addr string // address to host on, empty when not hosting
+	join_addr string // address to join, empty when not joining
+
+	listener net.Listener
+	pending  chan connection   // set while connecting
+	playing  chan round_result // set while a round waits on the other player
+	peer     *netplay.Peer
+	err      error // why the connection failed or ended
+}
+
+// StartOnline hosts or joins in the background, offering the current
+// rules and match length when hosting.
+func (g *Game) StartOnline() {
+
+	o := &g.online
+	o.err = nil
+
+	pending := make(chan connection, 1)
+	o.pending = pending
+
+	if o.host_addr != "" {
+
+		ln, err := net.Listen("tcp", o.host_addr)
+
+		if err != nil {
+			o.err = err
+			o.pending = nil
+			g.state = stateConnecting
+			return
+		}
+
+		o.listener = ln
+		rules, best_of := g.rules, g.best_of
+
+		go func() {
+			peer, err := netplay.Host(ln, rules, best_of)
+			ln.Close()
+			pending <- connection{peer, err}
+		}()
+
+	} else {
+
+		addr := o.join_addr
+
+		go func() {
+			peer, err := netplay.Join(addr)
+			pending <- connection{peer, err}
+		}()
+	}
+
+	g.state = stateConnecting
+}
+
+func (g *Game) UpdateConnecting() {
+
+	o := &g.online
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.CancelOnline()
+		g.state = stateGame
+		return
+	}
+
+	if o.pending == nil {
+		return
+	}
+
+	select {
+
+	case c := <-o.pending:
+		o.pending = nil
+		o.listener = nil
+
+		if c.err != nil {
+			o.err = c.err
+			return
+		}
+
+		o.peer = c.peer
+
+		// PLAY BY THE HOST'S RULES, WHICH A JOINER MAY NOT HAVE
+		i := slices.Index(g.rule_sets, c.peer.Rules)
+
+		if i < 0 {
+			g.rule_sets = append(g.rule_sets, c.peer.Rules)
+			i = len(g.rule_sets) - 1
+		}
+
+		g.SetRules(i)
+		g.SetBestOf(c.peer.BestOf)
+		g.SetMode(modeOnline)
+
+		g.state = stateGame
+
+	default:
+	}
+}
+
+// CancelOnline gives up on a connection still being made.
+func (g *Game) CancelOnline() {
+
+	o := &g.online
+
+	if o.listener != nil {
+		o.listener.Close()
+		o.listener = nil
+	}
+
+	// A JOIN MAY STILL GO THROUGH, HANG UP ON IT IF SO
+	if pending := o.pending; pending != nil {
+		go func() {
+			if c := <-pending; c.peer != nil {
+				c.peer.Close()
+			}
+		}()
+	}
+
+	o.pending = nil
+}
+
+// LeaveOnline hangs up, with err saying why if it wasn't the player's
+// choice, and goes back to playing the computer.
+func (g *Game) LeaveOnline(err error) {
+
+	o := &g.online
+
+	if o.peer != nil {
+		o.peer.Close()
+		o.peer = nil
+	}
+
+	o.playing = nil
+
+	g.SetMode(modeComputer)
+
+	if err != nil {
+		g.result = "Disconnected"
+		g.outcome = err.Error()
+	}
+}
+
+// PickOnline sends the player's move off. The round is played once the
+// other player has picked too.
+func (g *Game) PickOnline(move rps.Move) {
+
+	o := &g.online
+
+	if o.peer == nil || o.playing != nil {
+		return
+	}
+
+	playing := make(chan round_result, 1)
+	o.playing = playing
+	peer := o.peer
+
+	go func() {
+		theirs, err := peer.Play(move)
+		playing <- round_result{move, theirs, err}
+	}()
+
+	g.user_choice, g.comp_choice, g.outcome = g.rules.MoveName(move), "", ""
+	g.result = "Waiting for your opponent..."
+}
+
+// UpdateOnline plays the round once both moves are in.
+func (g *Game) UpdateOnline() {
+
+	o := &g.online
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.LeaveOnline(nil)
+		return
+	}
+
+	if o.playing == nil {
+		return
+	}
+
+	select {
+
+	case r := <-o.playing:
+		o.playing = nil
+
+		if r.err != nil {
+			g.LeaveOnline(r.err)
+			return
+		}
+
+		g.Reveal(r.mine, r.theirs)
+
+	default:
+	}
+}
+
+func (g *Game) DrawConnecting(screen *ebiten.Image) {
+
+	o := &g.online
+
+	status := "Joining " + o.join_addr + "..."
+	if o.host_addr != "" {
+		status = "Waiting for a player on " + o.host_addr + "..."
+	}
+
+	if o.err != nil {
+		status = fmt.Sprintf("Couldn't connect: %v", o.err)
+	}
+
+	text.Draw(screen, status, small_font, 20, screenHeight/2, color.White)
+	text.Draw(screen, "Esc - Play the computer instead", small_font, 20, screenHeight/2+40, stats_label)
+}
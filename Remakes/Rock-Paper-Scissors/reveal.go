@@ -0,0 +1,115 @@
+package main
+
+import (
+	"image/color"
+
+	"Rock-Paper-Scissors/rps"
+	"Rock-Paper-Scissors/tween"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+)
+
+// REVEAL
+// Both fists pump to "rock... paper... scissors..." and come down on
+// "shoot!" as the moves. The round is only played, and its result shown,
+// once the hands have been seen.
+const (
+	revealBeat = 0.32 // seconds for each word
+	revealBob  = 28   // pixels the fists rise on every beat
+	revealPop  = 0.25 // seconds the thrown hands take to grow
+	revealHold = 0.35 // seconds the thrown hands are shown, once grown, before the result
+)
+
+var reveal_words = []string{"Rock...", "Paper...", "Scissors...", "Shoot!"}
+
+var side_colours = [2]color.RGBA{{90, 140, 220, 255}, {220, 100, 100, 255}}
+
+type reveal struct {
+	mine, theirs rps.Move
+	timeline     tween.Timeline
+	bob          tween.Track // how far the fists are raised
+	pop          tween.Track // how big the thrown hands are
+	word         int         // the word being called
+	thrown       bool        // the moves are out
+}
+
+// Reveal plays the round between mine and theirs once the hands have
+// been thrown.
+func (g *Game) Reveal(mine, theirs rps.Move) {
+
+	r := &reveal{mine: mine, theirs: theirs}
+
+	// A BOB FOR EVERY WORD, THE LAST COMING DOWN AS THE THROW
+	for i := range reveal_words {
+
+		r.timeline.Cue(float64(i)*revealBeat, func() { r.word = i })
+
+		r.bob = r.bob.
+			Then(revealBeat/2, -revealBob, tween.OutQuad).
+			Then(revealBeat/2, 0, tween.InQuad)
+	}
+
+	shoot := r.bob.End()
+
+	r.timeline.Cue(shoot, func() { r.thrown = true })
+	r.pop = tween.Track{{Start: shoot, Duration: revealPop, From: 0.6, To: 1, Ease: tween.OutBack}}.Hold(revealHold)
+	r.timeline.Extend(r.pop.End())
+
+	g.user_choice, g.comp_choice, g.result, g.outcome = "", "", "", ""
+	g.reveal = r
+}
+
+// UpdateReveal moves the reveal on a tick, and plays the round once it's
+// over.
+func (g *Game) UpdateReveal() {
+
+	r := g.reveal
+	r.timeline.Advance(1 / float64(ebiten.TPS()))
+
+	if r.timeline.Done() {
+		g.reveal = nil
+		g.PlayRound(r.mine, r.theirs)
+	}
+}
+
+// DrawReveal draws the hands where the round's text will go.
+func (g *Game) DrawReveal(screen *ebiten.Image) {
+
+	r := g.reveal
+	now := r.timeline.Now()
+	names := side_names[g.mode]
+
+	// THE HANDS FILL WHAT ROOM THE MOVE BUTTONS LEAVE
+	room := textBottom - g.text_top
+	scale := min(1.2, float64(room-70)/handHeight)
+	y := float64(g.text_top + room/2 + 10)
+	label_y := min(int(y+handHeight/2*scale)+24, textBottom+4)
+
+	word := reveal_words[r.word]
+	width := font.MeasureString(game_font, word).Ceil()
+	text.Draw(screen, word, game_font, (screenWidth-width)/2, g.text_top, color.White)
+
+	moves := [2]string{"Rock", "Rock"}
+	labels := names
+
+	if r.thrown {
+
+		moves = [2]string{g.rules.MoveName(r.mine), g.rules.MoveName(r.theirs)}
+		labels = [2]string{names[0] + ": " + moves[0], names[1] + ": " + moves[1]}
+
+		scale *= r.pop.At(now)
+
+	} else {
+		y += r.bob.At(now)
+	}
+
+	for side, x := range []float64{screenWidth * 0.27, screenWidth * 0.73} {
+
+		DrawHand(screen, moves[side], x, y, scale, side == 1, side_colours[side])
+
+		width := font.MeasureString(small_font, labels[side]).Ceil()
+		text.Draw(screen, labels[side], small_font, int(x)-width/2, label_y, side_colours[side])
+	}
+}
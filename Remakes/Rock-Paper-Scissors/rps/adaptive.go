@@ -0,0 +1,206 @@
+package rps
+
+import "math/rand"
+
+// ADAPTIVE STRATEGIES
+// Each of these guesses how likely the other side is to throw each move
+// next, from what it has thrown so far, and plays the best reply. Old
+// rounds count for less and less, so a player who changes their habits is
+// caught up with.
+const (
+	frequencyMemory = 0.95 // weight a round keeps for every round after it
+	markovMemory    = 0.9
+	metaMemory      = 0.85
+)
+
+// FREQUENCY
+// Frequency expects the other side's favourite moves.
+type Frequency struct {
+	counts []float64
+	rules  *Rules
+	rng    *rand.Rand
+}
+
+// NewFrequency is a Frequency strategy breaking ties with rng.
+func NewFrequency(rules *Rules, rng *rand.Rand) *Frequency {
+
+	return &Frequency{counts: make([]float64, rules.N()), rules: rules, rng: rng}
+}
+
+func (f *Frequency) Throw() Move {
+
+	return f.rules.BestReply(f.counts, f.rng)
+}
+
+func (f *Frequency) Observe(mine, theirs Move) {
+
+	for m := range f.counts {
+		f.counts[m] *= frequencyMemory
+	}
+
+	f.counts[theirs]++
+}
+
+// MARKOV
+// Markov looks up what the other side threw after the same last Order
+// rounds, both sides' moves, before, and expects that again. An order of
+// one catches "always Paper after Rock", two or more catches longer habits
+// and reactions to what the computer threw.
+type Markov struct {
+	Order   int
+	history []int // rounds as theirs*N + mine, oldest first
+	counts  map[int][]float64
+	rules   *Rules
+	rng     *rand.Rand
+}
+
+// NewMarkov is a Markov strategy of the given order, breaking ties and
+// guessing with rng until it has seen enough.
+func NewMarkov(rules *Rules, order int, rng *rand.Rand) *Markov {
+
+	return &Markov{
+		Order:  max(order, 1),
+		counts: map[int][]float64{},
+		rules:  rules,
+		rng:    rng,
+	}
+}
+
+// context is the key of the last Order rounds, or false when there
+// haven't been that many yet.
+func (m *Markov) context() (int, bool) {
+
+	if len(m.history) < m.Order {
+		return 0, false
+	}
+
+	key := 0
+	for _, round := range m.history[len(m.history)-m.Order:] {
+		key = key*m.rules.N()*m.rules.N() + round
+	}
+
+	return key, true
+}
+
+func (m *Markov) Throw() Move {
+
+	key, ok := m.context()
+
+	if !ok {
+		return m.rules.Random(m.rng)
+	}
+
+	// NOTHING SEEN AFTER THIS CONTEXT YET IS A RANDOM THROW
+	return m.rules.BestReply(m.counts[key], m.rng)
+}
+
+func (m *Markov) Observe(mine, theirs Move) {
+
+	// WHAT CAME AFTER THE CONTEXT BEFORE THIS ROUND
+	if key, ok := m.context(); ok {
+
+		counts := m.counts[key]
+		if counts == nil {
+			counts = make([]float64, m.rules.N())
+			m.counts[key] = counts
+		}
+
+		for i := range counts {
+			counts[i] *= markovMemory
+		}
+
+		counts[theirs]++
+	}
+
+	m.history = append(m.history, int(theirs)*m.rules.N()+int(mine))
+
+	// ONLY THE LAST ORDER ROUNDS ARE EVER LOOKED AT
+	if len(m.history) > 4*m.Order {
+		m.history = append(m.history[:0], m.history[len(m.history)-m.Order:]...)
+	}
+}
+
+// META
+// Meta runs several predictors side by side and throws for whichever has
+// been right most lately. Each one is also tried shifted round to every
+// other move, which is what beats a player who has worked out the
+// predictor and is countering it. When nothing has been doing well it
+// throws at random, so it can't be exploited for long either.
+type Meta struct {
+	predictors []Strategy
+	scores     [][]float64 // by predictor and shift
+	throws     []Move      // this round's throw of every predictor
+	rules      *Rules
+	rng        *rand.Rand
+}
+
+// NewMeta is a Meta strategy over the frequency counter and Markov
+// predictors of orders one to three.
+func NewMeta(rules *Rules, rng *rand.Rand) *Meta {
+
+	predictors := []Strategy{
+		NewFrequency(rules, rng),
+		NewMarkov(rules, 1, rng),
+		NewMarkov(rules, 2, rng),
+		NewMarkov(rules, 3, rng),
+	}
+
+	scores := make([][]float64, len(predictors))
+	for i := range scores {
+		scores[i] = make([]float64, rules.N())
+	}
+
+	return &Meta{
+		predictors: predictors,
+		scores:     scores,
+		throws:     make([]Move, len(predictors)),
+		rules:      rules,
+		rng:        rng,
+	}
+}
+
+// shifted is predictor i's throw moved round by shift.
+func (m *Meta) shifted(i, shift int) Move {
+
+	return Move((int(m.throws[i]) + shift) % m.rules.N())
+}
+
+func (m *Meta) Throw() Move {
+
+	best, bestScore := m.rules.Random(m.rng), 0.0
+
+	for i, p := range m.predictors {
+
+		m.throws[i] = p.Throw()
+
+		for shift, score := range m.scores[i] {
+			if score > bestScore {
+				best = m.shifted(i, shift)
+				bestScore = score
+			}
+		}
+	}
+
+	return best
+}
+
+func (m *Meta) Observe(mine, theirs Move) {
+
+	// SCORE WHAT EVERY PREDICTOR WOULD HAVE THROWN
+	for i, p := range m.predictors {
+
+		for shift := range m.scores[i] {
+
+			m.scores[i][shift] *= metaMemory
+
+			switch m.rules.Judge(m.shifted(i, shift), theirs) {
+			case Win:
+				m.scores[i][shift]++
+			case Loss:
+				m.scores[i][shift]--
+			}
+		}
+
+		p.Observe(mine, theirs)
+	}
+}
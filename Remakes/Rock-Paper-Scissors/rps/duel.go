@@ -0,0 +1,67 @@
+package rps
+
+// TALLY
+// Tally counts how one side of a session did.
+type Tally struct {
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+}
+
+// Add counts one more round.
+func (t *Tally) Add(o Outcome) {
+
+	switch o {
+	case Win:
+		t.Wins++
+	case Loss:
+		t.Losses++
+	default:
+		t.Draws++
+	}
+}
+
+// Rounds is how many rounds have been counted.
+func (t Tally) Rounds() int {
+
+	return t.Wins + t.Losses + t.Draws
+}
+
+// WinRate is the share of rounds won, 0 before any are played.
+func (t Tally) WinRate() float64 {
+
+	if t.Rounds() == 0 {
+		return 0
+	}
+
+	return float64(t.Wins) / float64(t.Rounds())
+}
+
+// LossRate is the share of rounds lost.
+func (t Tally) LossRate() float64 {
+
+	if t.Rounds() == 0 {
+		return 0
+	}
+
+	return float64(t.Losses) / float64(t.Rounds())
+}
+
+// Duel plays a against b by rules for the given number of rounds and
+// tallies how a did.
+func Duel(rules *Rules, a, b Strategy, rounds int) Tally {
+
+	t := Tally{}
+
+	for range rounds {
+
+		ma, mb := a.Throw(), b.Throw()
+
+		t.Add(rules.Judge(ma, mb))
+
+		a.Observe(ma, mb)
+		b.Observe(mb, ma)
+	}
+
+	return t
+}
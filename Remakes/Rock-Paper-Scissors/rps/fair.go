@@ -0,0 +1,144 @@
+package rps
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+)
+
+// saltSize is how many random bytes go into the seal with the seed, so it
+// can't be found by hashing every seed there is.
+const saltSize = 32
+
+// ErrUnfair means a transcript doesn't hold up: the seed doesn't match
+// the hash published before the session, or the computer's throws aren't
+// the ones its seed makes.
+var ErrUnfair = errors.New("rps: the session wasn't played fair")
+
+// FAIR PLAY
+// A Transcript seals the seed behind the computer's throws before a
+// session, and records the session. The Hash is published first and the
+// Seed and Salt only at the end, when anyone can check that the seed
+// matches the hash and that every throw the computer made follows from
+// it, so the computer couldn't have looked at the player's moves early.
+// The strategy and rules are sealed in with the seed.
+type Transcript struct {
+	Hash     string  `json:"hash"` // hex SHA-256 of the seed, salt, strategy and rules
+	Seed     int64   `json:"seed"`
+	Salt     string  `json:"salt"` // hex
+	Strategy string  `json:"strategy"`
+	Rules    string  `json:"rules"` // in the rules file format
+	Throws   []Throw `json:"throws"`
+}
+
+// Throw is one round of a transcript, by move name.
+type Throw struct {
+	Player   string `json:"player"`
+	Computer string `json:"computer"`
+}
+
+// NewTranscript seals a fresh random seed for the computer playing
+// strategy by rules.
+func NewTranscript(rules *Rules, strategy string) (*Transcript, error) {
+
+	// THE STRATEGY HAS TO EXIST FOR THE SESSION TO BE CHECKED LATER
+	if _, err := New(strategy, rules, mathrand.New(mathrand.NewSource(0))); err != nil {
+		return nil, err
+	}
+
+	text, err := rules.MarshalText()
+
+	if err != nil {
+		return nil, err
+	}
+
+	random := make([]byte, 8+saltSize)
+
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	t := &Transcript{
+		Seed:     int64(binary.LittleEndian.Uint64(random)),
+		Salt:     hex.EncodeToString(random[8:]),
+		Strategy: strategy,
+		Rules:    string(text),
+	}
+
+	t.Hash = t.hash()
+
+	return t, nil
+}
+
+func (t *Transcript) hash() string {
+
+	sum := sha256.Sum256(fmt.Appendf(nil, "rps-fair:%d:%s:%s:%s", t.Seed, t.Salt, t.Strategy, t.Rules))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Rand is a fresh source of randomness from the sealed seed. The computer
+// has to make its strategy with it, straight away, for the throws to
+// check out.
+func (t *Transcript) Rand() *mathrand.Rand {
+
+	return mathrand.New(mathrand.NewSource(t.Seed))
+}
+
+// Add records a round, the player's move and then the computer's.
+func (t *Transcript) Add(rules *Rules, player, computer Move) {
+
+	t.Throws = append(t.Throws, Throw{rules.MoveName(player), rules.MoveName(computer)})
+}
+
+// Check replays the session from the seed and reports whether it was
+// played fair.
+func (t *Transcript) Check() error {
+
+	salt, err := hex.DecodeString(t.Salt)
+
+	switch {
+	case err != nil || len(salt) != saltSize:
+		return fmt.Errorf("%w: bad salt", ErrUnfair)
+	case subtle.ConstantTimeCompare([]byte(t.Hash), []byte(t.hash())) != 1:
+		return fmt.Errorf("%w: the seed doesn't match the hash", ErrUnfair)
+	}
+
+	rules, err := ParseRules("", []byte(t.Rules))
+
+	if err != nil {
+		return err
+	}
+
+	strategy, err := New(t.Strategy, rules, t.Rand())
+
+	if err != nil {
+		return err
+	}
+
+	// THE COMPUTER THROWS BEFORE IT SEES THE PLAYER'S MOVE, AND LEARNS
+	// FROM THE ROUND AFTER
+	for i, throw := range t.Throws {
+
+		player, ok := rules.Find(throw.Player)
+
+		if !ok {
+			return fmt.Errorf("%w: round %v has no move called %q", ErrUnfair, i+1, throw.Player)
+		}
+
+		computer := strategy.Throw()
+
+		if rules.MoveName(computer) != throw.Computer {
+			return fmt.Errorf("%w: in round %v the seed throws %v, not %v", ErrUnfair, i+1, rules.MoveName(computer), throw.Computer)
+		}
+
+		strategy.Observe(computer, player)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package rps
+
+// MATCH
+// A Match is best of BestOf rounds: the first side to win more than half
+// of them takes it. Draws are replayed, they don't count towards BestOf.
+type Match struct {
+	BestOf int
+	Tally  // how the player did, round by round
+}
+
+// NewMatch starts a best of bestOf match. An even bestOf is rounded up,
+// so there's always a winner.
+func NewMatch(bestOf int) *Match {
+
+	return &Match{BestOf: max(bestOf, 1) | 1}
+}
+
+// Needed is how many rounds it takes to win.
+func (m *Match) Needed() int {
+
+	return m.BestOf/2 + 1
+}
+
+// Play counts one more round, unless the match is already over.
+func (m *Match) Play(o Outcome) {
+
+	if !m.Over() {
+		m.Add(o)
+	}
+}
+
+// Over reports whether either side has won.
+func (m *Match) Over() bool {
+
+	return m.Wins >= m.Needed() || m.Losses >= m.Needed()
+}
+
+// Won reports whether the player took the match.
+func (m *Match) Won() bool {
+
+	return m.Wins >= m.Needed()
+}
+
+// STREAKS
+// Streaks follows runs of wins and losses. A draw ends either.
+type Streaks struct {
+	Current     int `json:"current"` // wins in a row if positive, losses in a row if negative
+	LongestWin  int `json:"longest_win"`
+	LongestLoss int `json:"longest_loss"`
+}
+
+// Add counts one more round.
+func (s *Streaks) Add(o Outcome) {
+
+	switch o {
+
+	case Win:
+		s.Current = max(s.Current, 0) + 1
+		s.LongestWin = max(s.LongestWin, s.Current)
+
+	case Loss:
+		s.Current = min(s.Current, 0) - 1
+		s.LongestLoss = max(s.LongestLoss, -s.Current)
+
+	default:
+		s.Current = 0
+	}
+}
@@ -0,0 +1,81 @@
+package rps
+
+import "math/rand"
+
+// PATTERNS
+// Simple habits people fall into. None of them learn, they're what the
+// adaptive strategies are measured against.
+
+// Cycle throws every move in turn.
+type Cycle struct {
+	next  Move
+	rules *Rules
+}
+
+func (c *Cycle) Throw() Move {
+
+	return c.next
+}
+
+func (c *Cycle) Observe(mine, theirs Move) {
+
+	c.next = Move((int(mine) + 1) % c.rules.N())
+}
+
+// Biased throws Favourite with probability Bias and otherwise anything.
+type Biased struct {
+	Favourite Move
+	Bias      float64
+	rules     *Rules
+	rng       *rand.Rand
+}
+
+func (b *Biased) Throw() Move {
+
+	if b.rng.Float64() < b.Bias {
+		return b.Favourite
+	}
+
+	return b.rules.Random(b.rng)
+}
+
+func (b *Biased) Observe(mine, theirs Move) {}
+
+// BeatLast throws what would have beaten the other side's last move.
+type BeatLast struct {
+	last  []float64 // all zero but the last move
+	rules *Rules
+	rng   *rand.Rand
+}
+
+func (b *BeatLast) Throw() Move {
+
+	return b.rules.BestReply(b.last, b.rng)
+}
+
+func (b *BeatLast) Observe(mine, theirs Move) {
+
+	b.last = make([]float64, b.rules.N())
+	b.last[theirs] = 1
+}
+
+// WinStay keeps a move that won and changes to another at random after a
+// loss or a draw.
+type WinStay struct {
+	move  Move
+	rules *Rules
+	rng   *rand.Rand
+}
+
+func (w *WinStay) Throw() Move {
+
+	return w.move
+}
+
+func (w *WinStay) Observe(mine, theirs Move) {
+
+	if w.rules.Judge(mine, theirs) != Win {
+		n := w.rules.N()
+		w.move = Move((int(mine) + 1 + w.rng.Intn(n-1)) % n)
+	}
+}
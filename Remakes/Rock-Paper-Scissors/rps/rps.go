@@ -0,0 +1,394 @@
+// Package rps is Rock-Paper-Scissors without the window: rule sets saying
+// which move beats which, and the computer players. It knows nothing about
+// ebiten, so thousands of rounds can be played headless to see how a
+// strategy does.
+package rps
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// RULE SET LIMITS
+const (
+	MinMoves       = 3
+	MaxMoves       = 15
+	rulesNameLimit = 40
+	defaultVerb    = "beats"
+)
+
+// MOVES
+// A Move is an index into the moves of a rule set.
+type Move int
+
+// OUTCOMES, ALWAYS FROM THE POINT OF VIEW OF WHOEVER'S ASKING
+type Outcome int
+
+const (
+	Draw Outcome = iota
+	Win
+	Loss
+)
+
+func (o Outcome) String() string {
+
+	switch o {
+	case Win:
+		return "Win"
+	case Loss:
+		return "Loss"
+	}
+
+	return "Draw"
+}
+
+// RULES
+// Rules is a game of the Rock-Paper-Scissors kind: some moves, and for
+// every two different moves which one wins and how it's said. Every move
+// beats exactly half of the others, so no move is better than any other.
+type Rules struct {
+	Name  string
+	Moves []string
+	beats [][]bool   // beats[a][b] when a wins against b
+	verbs [][]string // verbs[a][b], "covers" in "Paper covers Rock"
+}
+
+// ClassicText is plain Rock-Paper-Scissors in the rules file format.
+const ClassicText = `name: Rock-Paper-Scissors
+moves: Rock Paper Scissors
+Paper covers Rock
+Scissors cuts Paper
+Rock crushes Scissors
+`
+
+// Classic is plain Rock-Paper-Scissors, for when no rules file is picked.
+var Classic = mustParse("classic", ClassicText)
+
+func mustParse(name, text string) *Rules {
+
+	r, err := ParseRules(name, []byte(text))
+
+	if err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
+// ParseRules reads a rule set. Blank lines and lines starting with # are
+// skipped, and the rest are one of:
+//
+//	name: Rock-Paper-Scissors-Lizard-Spock
+//	moves: Rock Paper Scissors Lizard Spock
+//	Spock vaporizes Rock
+//	balanced
+//
+// A line naming two moves says the first beats the second, with whatever
+// is between them as the verb. balanced settles every pair no line has:
+// with the moves in a circle, each one beats the half of the others just
+// before it, the way Paper beats Rock and Scissors beats Paper.
+func ParseRules(name string, data []byte) (*Rules, error) {
+
+	r := &Rules{Name: name}
+	balanced := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for n := 1; scanner.Scan(); n++ {
+
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+
+		case strings.HasPrefix(line, "name:"):
+			r.Name = strings.TrimSpace(strings.TrimPrefix(line, "name:"))
+
+		case strings.HasPrefix(line, "moves:"):
+			if r.Moves != nil {
+				return nil, fmt.Errorf("%v: line %v: moves are already given", name, n)
+			}
+
+			if err := r.setMoves(strings.Fields(strings.TrimPrefix(line, "moves:"))); err != nil {
+				return nil, fmt.Errorf("%v: line %v: %w", name, n, err)
+			}
+
+		case line == "balanced":
+			balanced = true
+
+		default:
+			if err := r.parseBeat(strings.Fields(line)); err != nil {
+				return nil, fmt.Errorf("%v: line %v: %w", name, n, err)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if r.Moves == nil {
+		return nil, fmt.Errorf("%v: no moves: line", name)
+	}
+
+	if balanced {
+		r.balance()
+	}
+
+	if err := r.check(); err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	}
+
+	if len(r.Name) > rulesNameLimit {
+		r.Name = r.Name[:rulesNameLimit]
+	}
+
+	return r, nil
+}
+
+func (r *Rules) setMoves(moves []string) error {
+
+	if len(moves) < MinMoves || len(moves) > MaxMoves || len(moves)%2 == 0 {
+		return fmt.Errorf("%v moves, there have to be an odd number from %v to %v", len(moves), MinMoves, MaxMoves)
+	}
+
+	for i, m := range moves {
+		if slices.Contains(moves[:i], m) {
+			return fmt.Errorf("%q is in the moves twice", m)
+		}
+	}
+
+	r.Moves = moves
+	r.beats = make([][]bool, len(moves))
+	r.verbs = make([][]string, len(moves))
+
+	for i := range moves {
+		r.beats[i] = make([]bool, len(moves))
+		r.verbs[i] = make([]string, len(moves))
+	}
+
+	return nil
+}
+
+// parseBeat reads "Winner verb Loser", where the verb can be any number
+// of words, even none.
+func (r *Rules) parseBeat(fields []string) error {
+
+	if r.Moves == nil {
+		return fmt.Errorf("the moves: line has to come first")
+	}
+
+	if len(fields) < 2 {
+		return fmt.Errorf("expected winner verb loser, got %q", strings.Join(fields, " "))
+	}
+
+	winner, ok := r.Find(fields[0])
+	if !ok {
+		return fmt.Errorf("unknown move %q", fields[0])
+	}
+
+	loser, ok := r.Find(fields[len(fields)-1])
+	if !ok {
+		return fmt.Errorf("unknown move %q", fields[len(fields)-1])
+	}
+
+	switch {
+	case winner == loser:
+		return fmt.Errorf("%v can't beat itself", fields[0])
+	case r.beats[winner][loser]:
+		return fmt.Errorf("%v already beats %v", fields[0], fields[len(fields)-1])
+	case r.beats[loser][winner]:
+		return fmt.Errorf("%v already beats %v", fields[len(fields)-1], fields[0])
+	}
+
+	verb := strings.Join(fields[1:len(fields)-1], " ")
+	if verb == "" {
+		verb = defaultVerb
+	}
+
+	r.beats[winner][loser] = true
+	r.verbs[winner][loser] = verb
+
+	return nil
+}
+
+// balance fills in every pair nothing was said about.
+func (r *Rules) balance() {
+
+	n := r.N()
+
+	for a := range n {
+		for step := 1; step <= n/2; step++ {
+
+			b := (a - step + n) % n
+
+			if !r.beats[a][b] && !r.beats[b][a] {
+				r.beats[a][b] = true
+				r.verbs[a][b] = defaultVerb
+			}
+		}
+	}
+}
+
+// check makes sure every pair of moves is settled and that every move
+// wins as often as it loses.
+func (r *Rules) check() error {
+
+	for a := range r.N() {
+
+		wins := 0
+
+		for b := range r.N() {
+
+			if a != b && !r.beats[a][b] && !r.beats[b][a] {
+				return fmt.Errorf("nothing says who wins between %v and %v", r.Moves[a], r.Moves[b])
+			}
+
+			if r.beats[a][b] {
+				wins++
+			}
+		}
+
+		if wins != r.N()/2 {
+			return fmt.Errorf("%v beats %v moves, every move has to beat exactly %v", r.Moves[a], wins, r.N()/2)
+		}
+	}
+
+	return nil
+}
+
+// N is the number of moves.
+func (r *Rules) N() int {
+
+	return len(r.Moves)
+}
+
+// Find is the move called name.
+func (r *Rules) Find(name string) (Move, bool) {
+
+	i := slices.Index(r.Moves, name)
+
+	return Move(i), i >= 0
+}
+
+// MoveName is what move m is called.
+func (r *Rules) MoveName(m Move) string {
+
+	if m < 0 || int(m) >= r.N() {
+		return fmt.Sprintf("Move(%d)", int(m))
+	}
+
+	return r.Moves[m]
+}
+
+// Beats reports whether a wins against b.
+func (r *Rules) Beats(a, b Move) bool {
+
+	return r.beats[a][b]
+}
+
+// Judge is how mine does against theirs.
+func (r *Rules) Judge(mine, theirs Move) Outcome {
+
+	switch {
+	case r.beats[mine][theirs]:
+		return Win
+	case r.beats[theirs][mine]:
+		return Loss
+	}
+
+	return Draw
+}
+
+// Describe says what happened between two moves, "Spock vaporizes Rock",
+// with the winner first whichever order they're given in.
+func (r *Rules) Describe(a, b Move) string {
+
+	switch {
+	case r.beats[a][b]:
+		return fmt.Sprintf("%v %v %v", r.Moves[a], r.verbs[a][b], r.Moves[b])
+	case r.beats[b][a]:
+		return fmt.Sprintf("%v %v %v", r.Moves[b], r.verbs[b][a], r.Moves[a])
+	}
+
+	return fmt.Sprintf("%v and %v", r.Moves[a], r.Moves[b])
+}
+
+// Random is any move, evenly.
+func (r *Rules) Random(rng *rand.Rand) Move {
+
+	return Move(rng.Intn(r.N()))
+}
+
+// BestReply is the move that does best against a player who throws each
+// move as often as weights says: the most expected wins less losses. Ties
+// are broken at random, so no weights at all gives a random move.
+func (r *Rules) BestReply(weights []float64, rng *rand.Rand) Move {
+
+	best := []Move{}
+	top := 0.0
+
+	for m := range r.N() {
+
+		score := 0.0
+
+		for theirs, w := range weights {
+			switch r.Judge(Move(m), Move(theirs)) {
+			case Win:
+				score += w
+			case Loss:
+				score -= w
+			}
+		}
+
+		switch {
+		case len(best) == 0 || score > top:
+			best = append(best[:0], Move(m))
+			top = score
+		case score == top:
+			best = append(best, Move(m))
+		}
+	}
+
+	return best[rng.Intn(len(best))]
+}
+
+// MarshalText writes the rule set out in the format ParseRules reads,
+// every pair spelled out.
+func (r *Rules) MarshalText() ([]byte, error) {
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "name: %v\n", r.Name)
+	fmt.Fprintf(&b, "moves: %v\n", strings.Join(r.Moves, " "))
+
+	for winner := range r.N() {
+		for loser := range r.N() {
+			if r.beats[winner][loser] {
+				fmt.Fprintf(&b, "%v %v %v\n", r.Moves[winner], r.verbs[winner][loser], r.Moves[loser])
+			}
+		}
+	}
+
+	return []byte(b.String()), nil
+}
+
+// LoadRules reads the rules file at path.
+func LoadRules(path string) (*Rules, error) {
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseRules(filepath.Base(path), data)
+}
@@ -0,0 +1,131 @@
+package rps
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// STRATEGY
+// A Strategy plays one side of a session. Throw picks its next move, and
+// Observe tells it how the round went once both moves are out, so it can
+// learn from the other side's history. Strategies keep their own memory,
+// a new session wants a new one.
+type Strategy interface {
+	Throw() Move
+	Observe(mine, theirs Move)
+}
+
+// A Maker makes a fresh strategy for playing by rules, drawing its
+// randomness from rng.
+type Maker func(rules *Rules, rng *rand.Rand) Strategy
+
+// a registered strategy and how to make a fresh one
+type entry struct {
+	name     string
+	about    string
+	make     Maker
+	adaptive bool
+}
+
+var strategies = []entry{
+	{"random", "uniformly at random, the old GenerateCompChoice", func(r *Rules, rng *rand.Rand) Strategy { return NewRandom(r, rng) }, false},
+	{"frequency", "beats the moves the other side throws most", func(r *Rules, rng *rand.Rand) Strategy { return NewFrequency(r, rng) }, true},
+	{"markov", "predicts the next move from the last two rounds", func(r *Rules, rng *rand.Rand) Strategy { return NewMarkov(r, 2, rng) }, true},
+	{"meta", "plays whichever predictor has been doing best lately", func(r *Rules, rng *rand.Rand) Strategy { return NewMeta(r, rng) }, true},
+
+	// PATTERNS A PERSON MIGHT FALL INTO, FOR THE ADAPTIVE ONES TO PLAY AGAINST
+	{"cycle", "every move in turn, round and round", func(r *Rules, rng *rand.Rand) Strategy { return &Cycle{rules: r} }, false},
+	{"biased", "the first move half the time, otherwise at random", func(r *Rules, rng *rand.Rand) Strategy { return &Biased{Favourite: 0, Bias: 0.5, rules: r, rng: rng} }, false},
+	{"beat-last", "throws what would have beaten the other side's last move", func(r *Rules, rng *rand.Rand) Strategy { return &BeatLast{rules: r, rng: rng} }, false},
+	{"win-stay", "keeps a winning move and switches after losing", func(r *Rules, rng *rand.Rand) Strategy { return &WinStay{rules: r, rng: rng} }, false},
+}
+
+// Register adds a strategy New can make by name, after the built in ones.
+// It's meant to be called from an init function, and panics if the name is
+// taken, as two strategies of the same name couldn't be told apart.
+func Register(name, about string, make Maker) {
+
+	name = strings.ToLower(name)
+
+	for _, e := range strategies {
+		if e.name == name {
+			panic(fmt.Sprintf("rps: a strategy called %q is already registered", name))
+		}
+	}
+
+	strategies = append(strategies, entry{name, about, make, false})
+}
+
+// Names lists every strategy New knows, in order.
+func Names() []string {
+
+	names := []string{}
+
+	for _, e := range strategies {
+		names = append(names, e.name)
+	}
+
+	return names
+}
+
+// Adaptive lists the strategies that learn from the other side, the ones
+// worth having as the computer.
+func Adaptive() []string {
+
+	names := []string{}
+
+	for _, e := range strategies {
+		if e.adaptive {
+			names = append(names, e.name)
+		}
+	}
+
+	return names
+}
+
+// About is a line on what the strategy called name does.
+func About(name string) string {
+
+	for _, e := range strategies {
+		if e.name == name {
+			return e.about
+		}
+	}
+
+	return ""
+}
+
+// New makes a fresh strategy by name for playing by rules, drawing its
+// randomness from rng.
+func New(name string, rules *Rules, rng *rand.Rand) (Strategy, error) {
+
+	for _, e := range strategies {
+		if e.name == strings.ToLower(name) {
+			return e.make(rules, rng), nil
+		}
+	}
+
+	return nil, fmt.Errorf("rps: no strategy called %q, try one of %v", name, strings.Join(Names(), ", "))
+}
+
+// RANDOM
+// Random throws uniformly and can't be beaten or exploited over a long
+// session: whatever the other side throws, it wins as often as it loses.
+type Random struct {
+	rules *Rules
+	rng   *rand.Rand
+}
+
+// NewRandom is a Random strategy drawing from rng.
+func NewRandom(rules *Rules, rng *rand.Rand) *Random {
+
+	return &Random{rules: rules, rng: rng}
+}
+
+func (r *Random) Throw() Move {
+
+	return r.rules.Random(r.rng)
+}
+
+func (r *Random) Observe(mine, theirs Move) {}
@@ -0,0 +1,209 @@
+package rps
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// TOURNAMENT
+// Every strategy plays every other one in a number of duels, each started
+// afresh from its own seed and judged by the same Rules.Judge the game
+// uses. A round won is worth a point, a draw half and a loss nothing.
+
+// z95 is how many standard errors either side of a mean a 95% confidence
+// interval reaches.
+const z95 = 1.96
+
+// MinDuels is the fewest duels a pairing can be played in. It takes two to
+// see how much a pairing's result varies.
+const MinDuels = 2
+
+// Pairing is how A did against B over every round they played.
+type Pairing struct {
+	A, B string
+	Tally
+}
+
+// Standing is one strategy's place on the leaderboard. Score is its
+// points per round over the whole tournament, and the real score lies
+// between Low and High with 95% confidence. The interval comes from how
+// much its score varied between duels with the same opponent, not from
+// single rounds: rounds within a duel follow from each other, so they'd
+// make it look far surer than it is.
+type Standing struct {
+	Name string
+	Tally
+	Score, Low, High float64
+}
+
+// Tournament is the outcome of a round robin.
+type Tournament struct {
+	Rules       *Rules
+	Rounds      int // rounds in every duel
+	Duels       int // duels in every pairing
+	Leaderboard []Standing
+	Pairings    []Pairing // both ways round, A's tally against B
+}
+
+// RoundRobin plays every pair of the named strategies in duels duels of
+// rounds rounds each. Each duel is seeded on its own from seed, so they
+// can run side by side and still come out the same every time.
+func RoundRobin(rules *Rules, names []string, rounds, duels int, seed int64) (*Tournament, error) {
+
+	if duels < MinDuels {
+		return nil, fmt.Errorf("rps: a pairing needs at least %v duels to tell how sure its result is, not %v", MinDuels, duels)
+	}
+
+	// MAKE SURE EVERY NAME IS KNOWN BEFORE STARTING ANY
+	for _, name := range names {
+		if _, err := New(name, rules, rand.New(rand.NewSource(seed))); err != nil {
+			return nil, err
+		}
+	}
+
+	t := &Tournament{Rules: rules, Rounds: rounds, Duels: duels}
+
+	type pair struct{ a, b int }
+	pairs := []pair{}
+
+	for a := range names {
+		for b := a + 1; b < len(names); b++ {
+			pairs = append(pairs, pair{a, b})
+		}
+	}
+
+	// EVERY DUEL OF EVERY PAIRING, BY PAIRING THEN DUEL
+	tallies := make([][]Tally, len(pairs))
+	wg := sync.WaitGroup{}
+
+	for i, p := range pairs {
+
+		tallies[i] = make([]Tally, duels)
+
+		for d := range duels {
+
+			wg.Add(1)
+
+			go func() {
+
+				defer wg.Done()
+
+				rng := rand.New(rand.NewSource(seed + int64(i*duels+d)))
+
+				a, _ := New(names[p.a], rules, rng)
+				b, _ := New(names[p.b], rules, rng)
+
+				tallies[i][d] = Duel(rules, a, b, rounds)
+			}()
+		}
+	}
+
+	wg.Wait()
+
+	// EVERY PAIRING COUNTS FOR BOTH SIDES
+	standings := make([]Standing, len(names))
+	for i, name := range names {
+		standings[i].Name = name
+	}
+
+	// THE VARIANCE OF EACH STRATEGY'S MEAN SCORE AGAINST EACH OPPONENT, SUMMED
+	variances := make([]float64, len(names))
+
+	for i, p := range pairs {
+
+		tally := Tally{}
+		scores := make([]float64, duels)
+
+		for d, duel := range tallies[i] {
+			tally.add(duel)
+			scores[d] = duel.points()
+		}
+
+		flipped := Tally{Wins: tally.Losses, Losses: tally.Wins, Draws: tally.Draws}
+
+		t.Pairings = append(t.Pairings, Pairing{names[p.a], names[p.b], tally}, Pairing{names[p.b], names[p.a], flipped})
+		standings[p.a].add(tally)
+		standings[p.b].add(flipped)
+
+		// B'S SCORE IS ONE LESS A'S, SO IT VARIES JUST AS MUCH
+		v := sampleVariance(scores) / float64(duels)
+		variances[p.a] += v
+		variances[p.b] += v
+	}
+
+	for i := range standings {
+
+		s := &standings[i]
+
+		if s.Rounds() == 0 {
+			continue
+		}
+
+		// EVERY OPPONENT COUNTS THE SAME, SO THE SCORE IS THE MEAN OF THEIRS
+		opponents := float64(len(names) - 1)
+		margin := z95 * math.Sqrt(variances[i]) / opponents
+
+		s.Score = s.points()
+		s.Low, s.High = s.Score-margin, s.Score+margin
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool { return standings[i].Score > standings[j].Score })
+
+	t.Leaderboard = standings
+
+	return t, nil
+}
+
+func (t *Tally) add(o Tally) {
+
+	t.Wins += o.Wins
+	t.Losses += o.Losses
+	t.Draws += o.Draws
+}
+
+// points is the points per round the tally is worth.
+func (t Tally) points() float64 {
+
+	if t.Rounds() == 0 {
+		return 0
+	}
+
+	return (float64(t.Wins) + float64(t.Draws)/2) / float64(t.Rounds())
+}
+
+// sampleVariance is how much xs vary about their mean, with n-1 below the
+// line since the mean comes from them too.
+func sampleVariance(xs []float64) float64 {
+
+	if len(xs) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+
+	sum := 0.0
+	for _, x := range xs {
+		sum += (x - mean) * (x - mean)
+	}
+
+	return sum / float64(len(xs)-1)
+}
+
+// Against is how a did against b, and false if they didn't meet.
+func (t *Tournament) Against(a, b string) (Tally, bool) {
+
+	for _, p := range t.Pairings {
+		if p.A == a && p.B == b {
+			return p.Tally, true
+		}
+	}
+
+	return Tally{}, false
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"unicode"
+
+	"Rock-Paper-Scissors/rps"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// RULE SETS
+// Every .txt file in rules/ is a rule set, in name order. Plain
+// Rock-Paper-Scissors is always there even if the folder isn't.
+const (
+	rulesDir      = "rules"
+	maxButtonCols = 5
+	buttonGap     = 10
+)
+
+func loadRules(dir string) []*rps.Rules {
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+
+	if err != nil {
+		log.Print(err)
+	}
+
+	sort.Strings(paths)
+
+	rule_sets := []*rps.Rules{}
+
+	for _, path := range paths {
+
+		rules, err := rps.LoadRules(path)
+
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		rule_sets = append(rule_sets, rules)
+	}
+
+	if len(rule_sets) == 0 {
+		rule_sets = append(rule_sets, rps.Classic)
+	}
+
+	return rule_sets
+}
+
+// useRules adds the rule set in the file at path, unless one of the same
+// name is already loaded, and returns its index.
+func useRules(rule_sets []*rps.Rules, path string) ([]*rps.Rules, int, error) {
+
+	rules, err := rps.LoadRules(path)
+
+	if err != nil {
+		return rule_sets, 0, err
+	}
+
+	for i, loaded := range rule_sets {
+		if loaded.Name == rules.Name {
+			return rule_sets, i, nil
+		}
+	}
+
+	return append(rule_sets, rules), len(rule_sets), nil
+}
+
+// SetRules switches to rule set i. The buttons are laid out again, a new
+// match starts, and so does the computer: what it learned about the old
+// moves is no use with the new ones.
+func (g *Game) SetRules(i int) {
+
+	g.rules_index = i
+	g.rules = g.rule_sets[i]
+	g.rules_button.str = "Rules: " + g.rules.Name
+
+	g.layoutButtons()
+
+	if g.match != nil {
+		g.NewMatch()
+	}
+
+	if err := g.SetStrategy(g.strategy_name); err != nil {
+		log.Print(err)
+	}
+}
+
+// NextRules moves on to the next rule set.
+func (g *Game) NextRules() {
+
+	g.SetRules((g.rules_index + 1) % len(g.rule_sets))
+}
+
+// layoutButtons makes a button for every move, up to five to a row with
+// the rows as even as they can be.
+func (g *Game) layoutButtons() {
+
+	n := g.rules.N()
+	rows := (n + maxButtonCols - 1) / maxButtonCols
+	cols := (n + rows - 1) / rows
+
+	width := min(buttonWidth, (screenWidth-buttonGap*(cols+1))/cols)
+
+	g.Buttons = []Button{}
+	letters := shortcutLetters(g.rules.Moves)
+
+	for i, label := range g.rules.Moves {
+
+		button := Button{
+			X:         (buttonGap + ((width + buttonGap) * (i % cols))),
+			Y:         40 + (buttonHeight+buttonGap)*(i/cols),
+			W:         width,
+			H:         buttonHeight,
+			str:       label,
+			move:      rps.Move(i),
+			underline: letters[i],
+		}
+
+		// THE FIRST NINE MOVES GO BY NUMBER TOO
+		names := []string{}
+
+		if i < 9 {
+			names = append(names, strconv.Itoa(i+1))
+		}
+
+		if letters[i] >= 0 {
+			names = append(names, label[letters[i]:letters[i]+1])
+		}
+
+		for _, name := range names {
+
+			var key ebiten.Key
+
+			if err := key.UnmarshalText([]byte(name)); err == nil {
+				button.keys = append(button.keys, key)
+			}
+		}
+
+		g.Buttons = append(g.Buttons, button)
+	}
+
+	// THE TEXT STARTS UNDER THE LAST ROW AND SQUEEZES UP TO FIT
+	g.text_top = 40 + rows*(buttonHeight+buttonGap) + 35
+	g.text_gap = min(50, (textBottom-g.text_top)/(textLines-1))
+}
+
+// shortcutLetters picks a letter key for every move, as an index into its
+// name, or -1 if it has none left. A move gets its first letter when no
+// other move starts with it, and otherwise the first of its letters still
+// free, so Rock, Paper and Scissors are R, P and S whatever else there is.
+func shortcutLetters(moves []string) []int {
+
+	letters := make([]int, len(moves))
+	taken := map[rune]bool{}
+
+	firsts := map[rune]int{}
+	for _, move := range moves {
+		firsts[firstLetter(move)]++
+	}
+
+	for i, move := range moves {
+
+		letters[i] = -1
+
+		if first := firstLetter(move); first != 0 && firsts[first] == 1 {
+			letters[i] = 0
+			taken[first] = true
+		}
+	}
+
+	for i, move := range moves {
+
+		if letters[i] >= 0 {
+			continue
+		}
+
+		for j, r := range move {
+
+			r = unicode.ToLower(r)
+
+			if r >= 'a' && r <= 'z' && !taken[r] {
+				letters[i] = j
+				taken[r] = true
+				break
+			}
+		}
+	}
+
+	return letters
+}
+
+// firstLetter is the lower case first letter of a move's name, 0 if it
+// doesn't start with one.
+func firstLetter(move string) rune {
+
+	if move == "" {
+		return 0
+	}
+
+	r := unicode.ToLower(rune(move[0]))
+
+	if r < 'a' || r > 'z' {
+		return 0
+	}
+
+	return r
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"Rock-Paper-Scissors/rps"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	statsFile    = "stats.json"
+	statsVersion = 1
+)
+
+// RECORD
+// How the player has done, over a session or over every session.
+type Record struct {
+	Rounds     rps.Tally      `json:"rounds"`
+	Matches    int            `json:"matches"`
+	MatchesWon int            `json:"matches_won"`
+	Streaks    rps.Streaks    `json:"streaks"`
+	Moves      map[string]int `json:"moves"` // throws of each move, by name, whatever the rules
+}
+
+func newRecord() Record {
+
+	return Record{Moves: map[string]int{}}
+}
+
+func (r *Record) addRound(move string, o rps.Outcome) {
+
+	r.Rounds.Add(o)
+	r.Streaks.Add(o)
+	r.Moves[move]++
+}
+
+func (r *Record) addMatch(won bool) {
+
+	r.Matches++
+
+	if won {
+		r.MatchesWon++
+	}
+}
+
+// STATS
+// The lifetime record, kept between runs.
+type Stats struct {
+	Version  int    `json:"version"`
+	Lifetime Record `json:"lifetime"`
+	path     string
+}
+
+// statsPath is where the stats live: a rock-paper-scissors folder under
+// the user's config directory, or the working directory without one.
+func statsPath() string {
+
+	dir, err := os.UserConfigDir()
+
+	if err != nil {
+		return statsFile
+	}
+
+	return filepath.Join(dir, "rock-paper-scissors", statsFile)
+}
+
+func newStats(path string) *Stats {
+
+	return &Stats{
+		Version:  statsVersion,
+		Lifetime: newRecord(),
+		path:     path,
+	}
+}
+
+// LoadStats reads the stats at path. A missing file is a fresh start, and
+// one that can't be read is moved aside to path.bad rather than lost.
+func LoadStats(path string) *Stats {
+
+	stats := newStats(path)
+
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats
+	}
+
+	if err == nil {
+		err = json.Unmarshal(data, stats)
+	}
+
+	if err == nil && stats.Version != statsVersion {
+		err = fmt.Errorf("unknown version %v", stats.Version)
+	}
+
+	if err != nil {
+		log.Printf("stats: %v is unreadable (%v), starting over", path, err)
+
+		if rename_err := os.Rename(path, path+".bad"); rename_err != nil {
+			log.Printf("stats: %v", rename_err)
+		}
+
+		return newStats(path)
+	}
+
+	if stats.Lifetime.Moves == nil {
+		stats.Lifetime.Moves = map[string]int{}
+	}
+
+	return stats
+}
+
+// Save writes the stats to a temporary file and renames it over the old
+// one, so a crash half way through never leaves a broken file behind.
+func (s *Stats) Save() error {
+
+	data, err := json.MarshalIndent(s, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// RecordRound counts a round in the session and lifetime records. Every
+// round is saved, there's nothing else to lose when the window closes.
+func (g *Game) RecordRound(move string, o rps.Outcome) {
+
+	g.session.addRound(move, o)
+	g.stats.Lifetime.addRound(move, o)
+
+	g.win_rates = append(g.win_rates, g.session.Rounds.WinRate())
+
+	if g.match.Over() {
+		g.session.addMatch(g.match.Won())
+		g.stats.Lifetime.addMatch(g.match.Won())
+	}
+
+	if err := g.stats.Save(); err != nil {
+		log.Printf("stats: %v", err)
+	}
+}
+
+// STATS SCREEN
+var (
+	stats_label = color.RGBA{150, 200, 200, 255}
+	chart_line  = color.RGBA{250, 200, 80, 255}
+	chart_axis  = color.RGBA{90, 90, 110, 255}
+	usage_bar   = color.RGBA{60, 90, 140, 255}
+)
+
+func (g *Game) UpdateStats() {
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.state = stateGame
+		return
+	}
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButton0) && g.stats_button.Hit(ebiten.CursorPosition()) {
+		g.state = stateGame
+	}
+}
+
+func percent(part, whole int) string {
+
+	if whole == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.0f%%", 100*float64(part)/float64(whole))
+}
+
+func (g *Game) DrawStats(screen *ebiten.Image) {
+
+	text.Draw(screen, "Statistics", game_font, 10, 28, color.White)
+
+	// SESSION AND LIFETIME SIDE BY SIDE
+	rows := [][3]string{{"", "Session", "Lifetime"}}
+
+	for _, r := range []struct {
+		name string
+		get  func(r *Record) string
+	}{
+		{"Rounds", func(r *Record) string { return fmt.Sprint(r.Rounds.Rounds()) }},
+		{"Won / Lost / Drawn", func(r *Record) string {
+			return fmt.Sprintf("%v / %v / %v", r.Rounds.Wins, r.Rounds.Losses, r.Rounds.Draws)
+		}},
+		{"Win Rate", func(r *Record) string { return percent(r.Rounds.Wins, r.Rounds.Rounds()) }},
+		{"Matches Won", func(r *Record) string { return fmt.Sprintf("%v of %v", r.MatchesWon, r.Matches) }},
+		{"Longest Win Streak", func(r *Record) string { return fmt.Sprint(r.Streaks.LongestWin) }},
+		{"Longest Loss Streak", func(r *Record) string { return fmt.Sprint(r.Streaks.LongestLoss) }},
+	} {
+		rows = append(rows, [3]string{r.name, r.get(&g.session), r.get(&g.stats.Lifetime)})
+	}
+
+	for i, row := range rows {
+
+		clr := color.Color(color.White)
+		if i == 0 {
+			clr = stats_label
+		}
+
+		y := 70 + i*22
+		text.Draw(screen, row[0], small_font, 10, y, stats_label)
+		text.Draw(screen, row[1], small_font, 200, y, clr)
+		text.Draw(screen, row[2], small_font, 310, y, clr)
+	}
+
+	// HOW OFTEN EACH MOVE OF THESE RULES GETS THROWN, THE TEXT OVER ITS BAR
+	text.Draw(screen, "Your Moves", small_font, 460, 70, stats_label)
+
+	n := g.rules.N()
+	bar_gap := min(22, 150/n)
+	thrown := 0
+
+	for _, name := range g.rules.Moves {
+		thrown += g.session.Moves[name]
+	}
+
+	for i, name := range g.rules.Moves {
+
+		y := 78 + i*bar_gap
+		count := g.session.Moves[name]
+
+		if thrown > 0 {
+			vector.DrawFilledRect(screen, 460, float32(y), float32(170*count/thrown), float32(bar_gap-4), usage_bar, false)
+		}
+
+		label := fmt.Sprintf("%v %v", name, percent(count, thrown))
+		text.Draw(screen, label, small_font, 464, y+bar_gap-6, color.White)
+	}
+
+	g.DrawWinRateChart(screen, 10, 250, 620, 160)
+
+	// WHERE THE COMPUTER'S THROWS COME FROM, FOR PLAYING THE SESSION AGAIN
+	// OR CHECKING IT WAS FAIR
+	if g.fair != nil {
+		text.Draw(screen, "Seed hash, the seed is revealed when you ask", small_font, 10, 438, stats_label)
+		text.Draw(screen, g.fair.Hash, small_font, 10, 460, color.White)
+	} else {
+		text.Draw(screen, fmt.Sprintf("Session seed %v, play again from it with -seed", g.seed), small_font, 10, 450, stats_label)
+	}
+
+	// THE STATS BUTTON TAKES YOU BACK FROM HERE
+	back := g.stats_button
+	back.str = "Back"
+	g.DrawButton(screen, back)
+}
+
+// DrawWinRateChart plots the share of rounds won so far after every
+// round of the session, with a line where a player throwing at random
+// would end up.
+func (g *Game) DrawWinRateChart(screen *ebiten.Image, x, y, w, h float32) {
+
+	text.Draw(screen, "Win Rate This Session", small_font, int(x), int(y)-8, stats_label)
+
+	vector.StrokeRect(screen, x, y, w, h, 1, chart_axis, false)
+
+	// A RANDOM THROW WINS HALF OF THE ROUNDS THAT AREN'T DRAWN
+	n := float32(g.rules.N())
+	chance := y + h - h*(n-1)/(2*n)
+
+	for dash := x; dash < x+w; dash += 12 {
+		vector.StrokeLine(screen, dash, chance, min(dash+6, x+w), chance, 1, chart_axis, false)
+	}
+
+	text.Draw(screen, "100%", small_font, int(x+w)-40, int(y)+16, chart_axis)
+	text.Draw(screen, "0%", small_font, int(x+w)-24, int(y+h)-4, chart_axis)
+
+	if len(g.win_rates) < 2 {
+		text.Draw(screen, "Play a few rounds first", small_font, int(x)+10, int(y+h/2), chart_axis)
+		return
+	}
+
+	step := w / float32(len(g.win_rates)-1)
+
+	for i := 1; i < len(g.win_rates); i++ {
+
+		x0, y0 := x+float32(i-1)*step, y+h-h*float32(g.win_rates[i-1])
+		x1, y1 := x+float32(i)*step, y+h-h*float32(g.win_rates[i])
+
+		vector.StrokeLine(screen, x0, y0, x1, y1, 2, chart_line, false)
+	}
+}
@@ -0,0 +1,207 @@
+// Package tween moves numbers over time, for animating the screen. A
+// Tween takes one value from one number to another, a Track strings
+// tweens together, and a Timeline keeps the clock and fires cues as it
+// passes them. Nothing here draws, so it works in seconds and leaves what
+// the numbers mean to the caller.
+package tween
+
+// EASING
+// An Ease bends progress through a tween, 0 at the start and 1 at the end,
+// so the value can speed up, slow down or overshoot on the way.
+type Ease func(t float64) float64
+
+// Linear moves at the same speed all the way.
+func Linear(t float64) float64 {
+
+	return t
+}
+
+// InQuad starts slow and speeds up, like something falling.
+func InQuad(t float64) float64 {
+
+	return t * t
+}
+
+// OutQuad starts fast and slows down, like something thrown up.
+func OutQuad(t float64) float64 {
+
+	return t * (2 - t)
+}
+
+// OutBack overshoots the end a little and settles back, for things that
+// pop into view.
+func OutBack(t float64) float64 {
+
+	const s = 1.70158
+
+	t--
+	return 1 + t*t*((s+1)*t+s)
+}
+
+// TWEEN
+// A Tween takes a value from From to To over Duration seconds, starting
+// Start seconds into its timeline. A nil Ease is Linear.
+type Tween struct {
+	Start, Duration float64
+	From, To        float64
+	Ease            Ease
+}
+
+// At is the tween's value t seconds into its timeline: From before it
+// starts and To once it's over.
+func (tw Tween) At(t float64) float64 {
+
+	progress := 1.0
+
+	if tw.Duration > 0 {
+		progress = min(max((t-tw.Start)/tw.Duration, 0), 1)
+	} else if t < tw.Start {
+		progress = 0
+	}
+
+	ease := tw.Ease
+	if ease == nil {
+		ease = Linear
+	}
+
+	return tw.From + (tw.To-tw.From)*ease(progress)
+}
+
+// End is when the tween is over.
+func (tw Tween) End() float64 {
+
+	return tw.Start + tw.Duration
+}
+
+// TRACK
+// A Track is one value tweened again and again, tweens in the order they
+// start. Between tweens the value holds where the last one left it. Built
+// with Then, a track starts from 0 at time 0.
+type Track []Tween
+
+// At is the track's value t seconds into its timeline, from the last
+// tween to have started by then, or the first if none has.
+func (tr Track) At(t float64) float64 {
+
+	if len(tr) == 0 {
+		return 0
+	}
+
+	current := tr[0]
+
+	for _, tw := range tr[1:] {
+		if tw.Start > t {
+			break
+		}
+		current = tw
+	}
+
+	return current.At(t)
+}
+
+// Then adds a tween that starts when the last one ends, from where it
+// left off, and returns the longer track.
+func (tr Track) Then(duration, to float64, ease Ease) Track {
+
+	start, from := 0.0, 0.0
+
+	if len(tr) > 0 {
+		last := tr[len(tr)-1]
+		start, from = last.End(), last.To
+	}
+
+	return append(tr, Tween{Start: start, Duration: duration, From: from, To: to, Ease: ease})
+}
+
+// Hold adds a pause of duration seconds to the end of the track.
+func (tr Track) Hold(duration float64) Track {
+
+	to := 0.0
+	if len(tr) > 0 {
+		to = tr[len(tr)-1].To
+	}
+
+	return tr.Then(duration, to, nil)
+}
+
+// End is when the track's last tween is over.
+func (tr Track) End() float64 {
+
+	if len(tr) == 0 {
+		return 0
+	}
+
+	return tr[len(tr)-1].End()
+}
+
+// TIMELINE
+// A Timeline is a clock with cues on it. Advance it by the seconds that
+// passed, and every cue it passes is run once, in order.
+type Timeline struct {
+	now    float64
+	length float64
+	cues   []cue
+}
+
+type cue struct {
+	at    float64
+	run   func()
+	fired bool
+}
+
+// Cue runs fn once the timeline reaches at seconds. Cues at the same time
+// run in the order they were added.
+func (tl *Timeline) Cue(at float64, fn func()) {
+
+	// KEEP THE CUES IN ORDER, AFTER ANY ALREADY AT THE SAME TIME
+	i := len(tl.cues)
+	for i > 0 && tl.cues[i-1].at > at {
+		i--
+	}
+
+	tl.cues = append(tl.cues, cue{})
+	copy(tl.cues[i+1:], tl.cues[i:])
+	tl.cues[i] = cue{at: at, run: fn}
+
+	tl.Extend(at)
+}
+
+// Extend makes the timeline last at least until end, so Done waits for a
+// track that ends after the last cue.
+func (tl *Timeline) Extend(end float64) {
+
+	tl.length = max(tl.length, end)
+}
+
+// Advance moves the clock on by dt seconds and runs the cues passed on the
+// way. A cue may add more; any already passed run on the next Advance.
+func (tl *Timeline) Advance(dt float64) {
+
+	tl.now += dt
+
+	for i := 0; i < len(tl.cues); i++ {
+
+		c := &tl.cues[i]
+
+		if c.at > tl.now {
+			break
+		}
+
+		if !c.fired {
+			c.fired = true
+			c.run()
+		}
+	}
+}
+
+// Now is how many seconds the timeline has run.
+func (tl *Timeline) Now() float64 {
+
+	return tl.now
+}
+
+// Done reports whether the timeline has reached its end.
+func (tl *Timeline) Done() bool {
+
+	return tl.now >= tl.length
+}
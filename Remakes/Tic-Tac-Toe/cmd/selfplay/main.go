@@ -0,0 +1,108 @@
+// Command selfplay plays the computer's levels against each other,
+// headless, and prints how every pairing went:
+//
+//	go run ./cmd/selfplay -games 200
+//	go run ./cmd/selfplay -size 15 -k 5 -games 4 -budget 100ms
+//
+// Every cell is the row level's wins, losses and draws against the
+// column, half the games with each side moving first. On 3×3, Perfect
+// should never lose a game.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+
+	"tic-tac-toe/ttt"
+)
+
+func main() {
+
+	games := flag.Int("games", 200, "games played for every pairing")
+	seed := flag.Int64("seed", 1, "seed for every engine's randomness")
+	size := flag.Int("size", 3, "squares across the board")
+	k := flag.Int("k", 0, "marks in a row to win, the board's size up to 5 if 0")
+	budget := flag.Duration("budget", ttt.DefaultBudget, "longest an engine searches a move")
+	flag.Parse()
+
+	if *k == 0 {
+		*k = min(*size, 5)
+	}
+
+	board, err := ttt.NewBoard(*size, *k)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+
+	fmt.Printf("%v×%v, %v in a row: %v games a pairing, won / lost / drawn by the row level\n\n", *size, *size, *k, *games)
+
+	fmt.Printf("%-10v", "")
+	for _, col := range ttt.Levels {
+		fmt.Printf("%18v", col)
+	}
+	fmt.Println()
+
+	for _, row := range ttt.Levels {
+
+		fmt.Printf("%-10v", row)
+
+		for _, col := range ttt.Levels {
+
+			won, lost, drawn := 0, 0, 0
+
+			for game := range *games {
+
+				// THE ROW LEVEL PLAYS X, WHICH MOVES FIRST, IN EVERY OTHER GAME
+				first := game%2 == 0
+				engines := map[string]*ttt.Engine{}
+
+				if first {
+					engines[ttt.X], engines[ttt.O] = ttt.NewEngine(row, rng), ttt.NewEngine(col, rng)
+				} else {
+					engines[ttt.X], engines[ttt.O] = ttt.NewEngine(col, rng), ttt.NewEngine(row, rng)
+				}
+
+				for _, engine := range engines {
+					engine.Budget = *budget
+				}
+
+				mine := ttt.O
+				if first {
+					mine = ttt.X
+				}
+
+				switch play(board.Clone(), engines) {
+				case mine:
+					won++
+				case ttt.Empty:
+					drawn++
+				default:
+					lost++
+				}
+			}
+
+			fmt.Printf("%18v", fmt.Sprintf("%v / %v / %v", won, lost, drawn))
+		}
+
+		fmt.Println()
+	}
+}
+
+// play plays a game out on board, X first, and returns the winner.
+func play(board *ttt.Board, engines map[string]*ttt.Engine) string {
+
+	mark := ttt.X
+
+	for !board.Over() {
+		board.Cells[engines[mark].Move(board, mark)] = mark
+		mark = ttt.Other(mark)
+	}
+
+	return board.Winner()
+}
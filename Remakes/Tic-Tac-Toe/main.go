@@ -1,9 +1,15 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"image/color"
 	"log"
+	"math/rand"
 	"os"
+	"time"
+
+	"tic-tac-toe/ttt"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -17,15 +23,29 @@ import (
 const (
 	screenwidth  = 600
 	screenHeight = 600
-	cellWidth    = 150
-	cellHeight   = 150
+
+	// THE BOARD FILLS THIS SQUARE WHATEVER ITS SIZE
+	boardX    = 75
+	boardY    = 75
+	boardSide = 450
+
+	// HOW LONG THE COMPUTER SEEMS TO THINK, IN SECONDS
+	thinkMin = 0.4
+	thinkMax = 0.9
 )
 
 var (
-	game_font font.Face
 	str_font  font.Face
+	mark_font font.Face // sized to the cells, made again when the board changes
+	font_data *opentype.Font
 )
 
+// the boards the board button goes through, squares across and marks in
+// a row to win
+var board_choices = [][2]int{{3, 3}, {4, 4}, {7, 5}, {15, 5}}
+
+var line_colour = color.RGBA{250, 200, 80, 255}
+
 type Cell struct {
 	X, Y, W, H int
 	char       string
@@ -37,12 +57,36 @@ type Reset struct {
 	visible    bool
 }
 
+// Button is one of the options along the top.
+type Button struct {
+	X, Y, W, H int
+	str        string
+}
+
+// Hit reports whether the point is on the button.
+func (b Button) Hit(x, y int) bool {
+
+	return x >= b.X && x <= b.X+b.W && y >= b.Y && y <= b.Y+b.H
+}
+
 type Game struct {
-	cells     [3][3]Cell
-	clicks    int
-	board     []string
-	game_over bool
-	reset     Reset
+	cells        [][]Cell
+	clicks       int
+	board        *ttt.Board
+	game_over    bool
+	reset        Reset
+	board_button Button
+
+	// WHO'S PLAYING: TWO PEOPLE, OR ONE AGAINST THE COMPUTER AS O
+	vs_computer  bool
+	level        ttt.Level
+	first        string   // the mark that moves first
+	thinking     int      // ticks the computer waits before moving
+	ai_move      chan int // the computer's square once it's found, set while it thinks
+	rng          *rand.Rand
+	mode_button  Button
+	level_button Button
+	first_button Button
 }
 
 func init() {
@@ -59,15 +103,7 @@ func init() {
 		log.Fatal(err)
 	}
 
-	game_font, err = opentype.NewFace(tt, &opentype.FaceOptions{
-		Size:    60,
-		DPI:     72,
-		Hinting: font.HintingFull,
-	})
-
-	if err != nil {
-		log.Fatal(err)
-	}
+	font_data = tt
 
 	str_font, err = opentype.NewFace(tt, &opentype.FaceOptions{
 		Size:    20,
@@ -93,45 +129,33 @@ func getWidth(str string, Font font.Face) int {
 	return width
 }
 
-func getCount(board []string) int {
-
-	count := 0
-
-	for _, char := range board {
-		if char == "" {
-			count++
-		}
-	}
-
-	return count
-}
-
 func main() {
 
-	ebiten.SetWindowSize(screenwidth, screenHeight)
-	ebiten.SetWindowTitle("Tic-Tac-Toe - The Fourth")
+	computer := flag.Bool("computer", false, "play the computer, which is O, instead of another person")
+	level_name := flag.String("level", ttt.Hard.String(), "how well the computer plays: Easy, Medium, Hard or Perfect")
+	size := flag.Int("size", 3, fmt.Sprintf("squares across the board, %v to %v", ttt.MinSize, ttt.MaxSize))
+	k := flag.Int("k", 0, "marks in a row to win, the board's size up to 5 if 0")
+	flag.Parse()
 
-	cells := [3][3]Cell{}
+	level, err := ttt.ParseLevel(*level_name)
 
-	board := []string{}
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	for i := 0; i < 10; i++ {
-		board = append(board, "")
+	if *k == 0 {
+		*k = min(*size, 5)
 	}
 
-	for i := 0; i < 3; i++ {
-		for j := 0; j < 3; j++ {
-			cells[i][j] = Cell{
-				X:      75 + (cellWidth * j),
-				Y:      75 + (cellHeight * i),
-				W:      cellWidth,
-				H:      cellHeight,
-				char:   "",
-				filled: false,
-			}
-		}
+	board, err := ttt.NewBoard(*size, *k)
+
+	if err != nil {
+		log.Fatal(err)
 	}
 
+	ebiten.SetWindowSize(screenwidth, screenHeight)
+	ebiten.SetWindowTitle("Tic-Tac-Toe - The Fourth")
+
 	reset := Reset{
 		X:       (screenwidth - 150) / 2,
 		Y:       530,
@@ -141,14 +165,43 @@ func main() {
 	}
 
 	game := &Game{
-		cells:     cells,
 		clicks:    0,
-		board:     board,
 		game_over: false,
 		reset:     reset,
+		board_button: Button{
+			X: 15,
+			Y: 536,
+			W: 195,
+			H: 32,
+		},
+		vs_computer: *computer,
+		level:       level,
+		first:       ttt.X,
+		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		mode_button: Button{
+			X: 15,
+			Y: 36,
+			W: 180,
+			H: 32,
+		},
+		level_button: Button{
+			X: 210,
+			Y: 36,
+			W: 180,
+			H: 32,
+		},
+		first_button: Button{
+			X: 405,
+			Y: 36,
+			W: 180,
+			H: 32,
+		},
 	}
 
-	err := ebiten.RunGame(game)
+	game.UpdateButtons()
+	game.SetBoard(board)
+
+	err = ebiten.RunGame(game)
 
 	if err != nil {
 		log.Fatal(err)
@@ -157,7 +210,9 @@ func main() {
 
 func (g *Game) Update() error {
 
+	g.isButtonClicked()
 	g.isCellClicked()
+	g.UpdateComputer()
 
 	if g.CheckWinner("X") || g.CheckWinner("O") || g.CheckDraw() {
 		g.game_over = true
@@ -180,13 +235,29 @@ func (g *Game) Update() error {
 
 func (g *Game) Draw(screen *ebiten.Image) {
 
-	for _, row := range g.cells {
-		for _, cell := range row {
+	// THE WINNING LINE STANDS OUT, WHICH ON A BIG BOARD IT OTHERWISE WOULDN'T
+	winning := map[int]bool{}
+	for _, square := range g.board.Line() {
+		winning[square] = true
+	}
+
+	// THINNER LINES BETWEEN SMALLER CELLS
+	border := float32(max(1, g.cells[0][0].W/75))
+	size := mark_font.Metrics().Ascent.Ceil()
+
+	for i, row := range g.cells {
+		for j, cell := range row {
 
 			vector.DrawFilledRect(screen, float32(cell.X), float32(cell.Y), float32(cell.W), float32(cell.H), color.White, false)
-			vector.DrawFilledRect(screen, float32(cell.X+2), float32(cell.Y+2), float32(cell.W-4), float32(cell.H-4), color.Black, false)
+			vector.DrawFilledRect(screen, float32(cell.X)+border, float32(cell.Y)+border, float32(cell.W)-2*border, float32(cell.H)-2*border, color.Black, false)
+
+			clr := color.Color(color.White)
+			if winning[g.board.Square(i, j)] {
+				clr = line_colour
+			}
 
-			text.Draw(screen, cell.char, game_font, cell.X+55, cell.Y+90, color.White)
+			X := cell.X + (cell.W-getWidth(cell.char, mark_font))/2
+			text.Draw(screen, cell.char, mark_font, X, cell.Y+(cell.H+size*3/4)/2, clr)
 		}
 	}
 
@@ -197,38 +268,61 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	o_win_str := "Player with O Wins!"
 	draw_str := "It's a Draw!"
 
+	if g.vs_computer {
+		x_str, o_str = "Your Move", "The Computer is Thinking..."
+		x_win_str, o_win_str = "You Win!", "The Computer Wins!"
+	}
+
 	if !(g.game_over) {
 
-		width := getWidth(x_str, str_font)
+		next_str := x_str
+		if g.Turn() == ttt.O {
+			next_str = o_str
+		}
+
+		width := getWidth(next_str, str_font)
 		X := (screenwidth - width) / 2
 
-		if g.clicks%2 == 0 {
-			text.Draw(screen, x_str, str_font, X, 30, color.White)
-		} else {
-			text.Draw(screen, o_str, str_font, X, 30, color.White)
-		}
+		text.Draw(screen, next_str, str_font, X, 26, color.White)
+
 	} else {
 
-		win_width := getWidth(x_win_str, str_font)
-		X := (screenwidth - win_width) / 2
+		X := (screenwidth - getWidth(x_win_str, str_font)) / 2
+		X_O := (screenwidth - getWidth(o_win_str, str_font)) / 2
 
 		draw_width := getWidth(draw_str, str_font)
 		X_D := (screenwidth - draw_width) / 2
 
 		if g.CheckWinner("X") {
 
-			text.Draw(screen, x_win_str, str_font, X, 30, color.White)
+			text.Draw(screen, x_win_str, str_font, X, 26, color.White)
 
 		} else if g.CheckWinner("O") {
 
-			text.Draw(screen, o_win_str, str_font, X, 30, color.White)
+			text.Draw(screen, o_win_str, str_font, X_O, 26, color.White)
 
 		} else {
 
-			text.Draw(screen, draw_str, str_font, X_D, 30, color.White)
+			text.Draw(screen, draw_str, str_font, X_D, 26, color.White)
 		}
 	}
 
+	// OPTIONS, THE LEVEL ONLY WITH A COMPUTER TO PLAY
+	buttons := []Button{g.mode_button, g.first_button, g.board_button}
+
+	if g.vs_computer {
+		buttons = append(buttons, g.level_button)
+	}
+
+	for _, button := range buttons {
+
+		vector.DrawFilledRect(screen, float32(button.X), float32(button.Y), float32(button.W), float32(button.H), color.White, false)
+		vector.DrawFilledRect(screen, float32(button.X+2), float32(button.Y+2), float32(button.W-4), float32(button.H-4), color.Black, false)
+
+		X := button.X + (button.W-getWidth(button.str, str_font))/2
+		text.Draw(screen, button.str, str_font, X, button.Y+23, color.White)
+	}
+
 	if g.reset.visible {
 
 		vector.DrawFilledRect(screen, float32(g.reset.X), float32(g.reset.Y), float32(g.reset.W), float32(g.reset.H), color.White, false)
@@ -243,44 +337,88 @@ func (g *Game) Layout(outsidewidth, outsideHeight int) (int, int) {
 	return screenwidth, screenHeight
 }
 
-func (g *Game) UpdateBoard() {
+// SetBoard switches to an empty board like b, laying the cells out to
+// fill the same square however many there are, and starts a new game.
+func (g *Game) SetBoard(b *ttt.Board) {
+
+	g.board = b
+	cell_size := boardSide / b.N
+
+	// WHAT'S LEFT OVER FROM ROUNDING GOES EVENLY EITHER SIDE
+	offset := (boardSide - cell_size*b.N) / 2
 
-	g.board[1] = g.cells[0][0].char
-	g.board[2] = g.cells[0][1].char
-	g.board[3] = g.cells[0][2].char
-	g.board[4] = g.cells[1][0].char
-	g.board[5] = g.cells[1][1].char
-	g.board[6] = g.cells[1][2].char
-	g.board[7] = g.cells[2][0].char
-	g.board[8] = g.cells[2][1].char
-	g.board[9] = g.cells[2][2].char
+	g.cells = make([][]Cell, b.N)
 
+	for i := range b.N {
+
+		g.cells[i] = make([]Cell, b.N)
+
+		for j := range b.N {
+			g.cells[i][j] = Cell{
+				X:      boardX + offset + (cell_size * j),
+				Y:      boardY + offset + (cell_size * i),
+				W:      cell_size,
+				H:      cell_size,
+				char:   "",
+				filled: false,
+			}
+		}
+	}
+
+	face, err := opentype.NewFace(font_data, &opentype.FaceOptions{
+		Size:    float64(cell_size) * 0.4,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mark_font = face
+
+	g.board_button.str = fmt.Sprintf("%vx%v, %v in a Row", b.N, b.N, b.K)
+
+	g.Restart()
 }
 
-func (g *Game) CheckWinner(marker string) bool {
+// NextBoard moves on to the next of the boards to choose from.
+func (g *Game) NextBoard() {
+
+	next := 0
 
-	if (g.board[1] == g.board[2] && g.board[2] == g.board[3] && g.board[1] == marker) ||
-		(g.board[4] == g.board[5] && g.board[5] == g.board[6] && g.board[4] == marker) ||
-		(g.board[7] == g.board[8] && g.board[8] == g.board[9] && g.board[9] == marker) ||
-		(g.board[1] == g.board[4] && g.board[4] == g.board[7] && g.board[1] == marker) ||
-		(g.board[2] == g.board[5] && g.board[5] == g.board[8] && g.board[2] == marker) ||
-		(g.board[3] == g.board[6] && g.board[6] == g.board[9] && g.board[3] == marker) ||
-		(g.board[1] == g.board[5] && g.board[5] == g.board[9] && g.board[1] == marker) ||
-		(g.board[3] == g.board[5] && g.board[5] == g.board[7] && g.board[3] == marker) {
+	for i, choice := range board_choices {
+		if choice == [2]int{g.board.N, g.board.K} {
+			next = (i + 1) % len(board_choices)
+		}
+	}
+
+	b, err := ttt.NewBoard(board_choices[next][0], board_choices[next][1])
 
-		return true
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	return false
+	g.SetBoard(b)
 }
 
-func (g *Game) CheckDraw() bool {
+func (g *Game) UpdateBoard() {
 
-	if !g.CheckWinner("X") && !g.CheckWinner("O") && getCount(g.board) <= 1 {
-		return true
+	for i, row := range g.cells {
+		for j, cell := range row {
+			g.board.Cells[g.board.Square(i, j)] = cell.char
+		}
 	}
+}
+
+func (g *Game) CheckWinner(marker string) bool {
 
-	return false
+	return g.board.Winner() == marker
+}
+
+func (g *Game) CheckDraw() bool {
+
+	return g.board.Winner() == ttt.Empty && g.board.Full()
 }
 
 func (g *Game) isCellClicked() {
@@ -296,19 +434,9 @@ func (g *Game) isCellClicked() {
 
 				if (x >= cell.X && x <= cell.X+cell.W) && (y >= cell.Y && y <= cell.Y+cell.H) {
 
-					if !cell.filled {
-
-						if g.clicks%2 == 0 {
-							cell.char = "X"
-						} else {
-							cell.char = "O"
-						}
-
-						g.clicks++
-
-						cell.filled = true
-
-						g.UpdateBoard()
+					// THE COMPUTER'S TURN ISN'T THE PLAYER'S TO TAKE
+					if !cell.filled && !g.ComputersTurn() {
+						g.Place(i, j)
 					}
 				}
 			}
@@ -325,23 +453,158 @@ func (g *Game) isResetClicked() {
 			x, y := ebiten.CursorPosition()
 
 			if (x >= g.reset.X && x <= g.reset.X+g.reset.W) && (y >= g.reset.Y && y <= g.reset.Y+g.reset.H) {
+				g.Restart()
+			}
+		}
+	}
+}
 
-				g.clicks = 0
+// Restart clears the board for a new game. A move the computer is still
+// thinking about is dropped.
+func (g *Game) Restart() {
 
-				for i, row := range g.cells {
-					for j := range row {
+	g.clicks = 0
 
-						cell := &g.cells[i][j]
+	for i, row := range g.cells {
+		for j := range row {
 
-						cell.filled = false
-						cell.char = ""
-					}
-				}
+			cell := &g.cells[i][j]
 
-				g.UpdateBoard()
-				g.game_over = false
-				g.reset.visible = false
-			}
+			cell.filled = false
+			cell.char = ""
 		}
 	}
+
+	g.UpdateBoard()
+	g.game_over = false
+	g.reset.visible = false
+
+	g.ai_move = nil
+	g.thinking = 0
+}
+
+// Turn is the mark to play next.
+func (g *Game) Turn() string {
+
+	if g.clicks%2 == 0 {
+		return g.first
+	}
+
+	return ttt.Other(g.first)
+}
+
+// ComputersTurn reports whether it's for the computer to move.
+func (g *Game) ComputersTurn() bool {
+
+	return g.vs_computer && g.Turn() == ttt.O
+}
+
+// Place puts the next mark in the cell at row i, column j.
+func (g *Game) Place(i, j int) {
+
+	cell := &g.cells[i][j]
+
+	cell.char = g.Turn()
+	cell.filled = true
+
+	g.clicks++
+
+	g.UpdateBoard()
+}
+
+// UpdateComputer has the computer move on its turn. It searches in the
+// background and waits a moment either way, so its moves don't just
+// appear.
+func (g *Game) UpdateComputer() {
+
+	if !g.ComputersTurn() || g.board.Over() {
+		return
+	}
+
+	if g.ai_move == nil {
+
+		ai_move := make(chan int, 1)
+		g.ai_move = ai_move
+
+		// A SEARCH OF ITS OWN, SO ONE LEFT OVER FROM A RESTART CAN'T CLASH
+		engine := ttt.NewEngine(g.level, rand.New(rand.NewSource(g.rng.Int63())))
+		board := g.board.Clone()
+
+		go func() {
+			ai_move <- engine.Move(board, ttt.O)
+		}()
+
+		think := thinkMin + (thinkMax-thinkMin)*g.rng.Float64()
+		g.thinking = int(think * float64(ebiten.TPS()))
+
+		return
+	}
+
+	if g.thinking > 0 {
+		g.thinking--
+		return
+	}
+
+	select {
+
+	case square := <-g.ai_move:
+		g.ai_move = nil
+
+		if square >= 0 {
+			g.Place(square/g.board.N, square%g.board.N)
+		}
+
+	default:
+	}
+}
+
+// UpdateButtons labels the options for how they're set.
+func (g *Game) UpdateButtons() {
+
+	g.mode_button.str = "Two Players"
+	g.first_button.str = g.first + " Goes First"
+
+	if g.vs_computer {
+
+		g.mode_button.str = "Vs Computer"
+		g.first_button.str = "You Go First"
+
+		if g.first == ttt.O {
+			g.first_button.str = "Computer First"
+		}
+	}
+
+	g.level_button.str = "Level: " + g.level.String()
+}
+
+// isButtonClicked changes an option and starts a new game with it.
+func (g *Game) isButtonClicked() {
+
+	if !inpututil.IsMouseButtonJustPressed(ebiten.MouseButton0) {
+		return
+	}
+
+	x, y := ebiten.CursorPosition()
+
+	switch {
+
+	case g.mode_button.Hit(x, y):
+		g.vs_computer = !g.vs_computer
+
+	case g.first_button.Hit(x, y):
+		g.first = ttt.Other(g.first)
+
+	case g.vs_computer && g.level_button.Hit(x, y):
+		g.level = ttt.Levels[(int(g.level)+1)%len(ttt.Levels)]
+
+	case g.board_button.Hit(x, y):
+		g.NextBoard()
+		return
+
+	default:
+		return
+	}
+
+	g.UpdateButtons()
+	g.Restart()
 }
@@ -0,0 +1,368 @@
+package ttt
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SEARCH LIMITS
+const (
+	// winScore is what a win is worth to the side that gets it, less a
+	// point for every move it took, so a quick win beats a slow one and a
+	// loss is put off as long as it can be. It's far above anything the
+	// evaluation gives a position short of a win.
+	winScore = 1 << 30
+
+	// DefaultBudget is how long an Engine searches a move without one.
+	DefaultBudget = 800 * time.Millisecond
+
+	// neighbourhood is how far from the marks already down a move is
+	// looked for. On a big board, a move out on its own is never the
+	// best one.
+	neighbourhood = 2
+
+	checkEvery = 1024 // nodes searched between looks at the clock
+)
+
+// LEVELS
+// A Level is how deep the computer looks and how often it makes a mistake
+// on purpose, playing a move that does worse than its best. Perfect
+// looks as far as it has time for and never slips, so on a 3×3 board it
+// can't be beaten.
+type Level int
+
+const (
+	Easy Level = iota
+	Medium
+	Hard
+	Perfect
+)
+
+// Levels lists every level, easiest first.
+var Levels = []Level{Easy, Medium, Hard, Perfect}
+
+var level_names = []string{"Easy", "Medium", "Hard", "Perfect"}
+
+// chance of a mistake on any move, by level
+var mistake_rates = []float64{0.5, 0.25, 0.08, 0}
+
+// moves looked ahead, by level, 0 for as many as there's time for
+var depth_limits = []int{1, 2, 4, 0}
+
+func (l Level) String() string {
+
+	if l < 0 || int(l) >= len(level_names) {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+
+	return level_names[l]
+}
+
+// ParseLevel is the level called name, in any case.
+func ParseLevel(name string) (Level, error) {
+
+	for i, level := range level_names {
+		if strings.EqualFold(level, name) {
+			return Level(i), nil
+		}
+	}
+
+	return 0, fmt.Errorf("ttt: no level called %q, try one of %v", name, strings.Join(level_names, ", "))
+}
+
+// ENGINE
+// An Engine picks moves with minimax and alpha-beta pruning, searching
+// one move deeper at a time until its level or its Budget says stop. A
+// small board is searched to the end; on a big one only moves near the
+// marks already down are tried, best looking first, and positions the
+// search stops at are scored by the lines each side could still make.
+// An Engine isn't safe to use from two goroutines at once.
+type Engine struct {
+	Level  Level
+	Budget time.Duration // how long a move may take, DefaultBudget if 0
+	rng    *rand.Rand
+
+	// THE SEARCH UNDER WAY
+	deadline time.Time
+	nodes    int
+	aborted  bool
+}
+
+// NewEngine is an engine playing at level, drawing its randomness from
+// rng.
+func NewEngine(level Level, rng *rand.Rand) *Engine {
+
+	return &Engine{Level: level, rng: rng}
+}
+
+// Move is the square the engine plays as mark, or -1 if the game is
+// over. The board is left as it was.
+func (e *Engine) Move(board *Board, mark string) int {
+
+	b := board.Clone()
+
+	if b.Over() {
+		return -1
+	}
+
+	moves := candidates(b, mark)
+
+	if len(moves) == 1 {
+		return moves[0]
+	}
+
+	budget := e.Budget
+	if budget == 0 {
+		budget = DefaultBudget
+	}
+
+	e.deadline = time.Now().Add(budget)
+	e.nodes, e.aborted = 0, false
+
+	max_depth := len(b.Free())
+	if limit := depth_limits[e.Level]; limit > 0 {
+		max_depth = min(max_depth, limit)
+	}
+
+	// DEEPER AND DEEPER, KEEPING WHAT THE LAST SEARCH TO FINISH FOUND
+	good, bad := moves[:1], moves[1:]
+
+	for depth := 1; depth <= max_depth; depth++ {
+
+		best, g, bd, ok := e.searchRoot(b, mark, moves, depth)
+
+		if !ok {
+			break
+		}
+
+		good, bad = g, bd
+
+		// THE BEST MOVES FIRST NEXT TIME ROUND, THEY CUT THE MOST
+		moves = append(slices.Clone(good), bad...)
+
+		// A WIN OR LOSS THAT'S BEEN SEEN WON'T CHANGE BY LOOKING FURTHER
+		if best > winScore/2 || best < -winScore/2 {
+			break
+		}
+	}
+
+	if len(bad) > 0 && e.rng.Float64() < mistake_rates[e.Level] {
+		return bad[e.rng.Intn(len(bad))]
+	}
+
+	// MOVES AS GOOD AS EACH OTHER ARE PICKED AT RANDOM, SO GAMES DIFFER
+	return good[e.rng.Intn(len(good))]
+}
+
+// searchRoot scores every move depth moves deep and splits them into the
+// best, all as good as each other, and the rest. It's false if time ran
+// out first.
+func (e *Engine) searchRoot(b *Board, mark string, moves []int, depth int) (int, []int, []int, bool) {
+
+	best := math.MinInt
+	good, bad := []int{}, []int{}
+
+	for i, square := range moves {
+
+		// AFTER THE FIRST MOVE, A WINDOW JUST UNDER THE BEST TELLS A MOVE
+		// AS GOOD FROM A WORSE ONE WITHOUT SCORING THE WORSE ONE EXACTLY
+		alpha := -math.MaxInt
+		if i > 0 {
+			alpha = best - 1
+		}
+
+		b.Cells[square] = mark
+		score := -e.negamax(b, Other(mark), square, 1, depth-1, -math.MaxInt, -alpha)
+		b.Cells[square] = Empty
+
+		if e.aborted {
+			return 0, nil, nil, false
+		}
+
+		switch {
+
+		case score > best:
+			bad = append(bad, good...)
+			good = []int{square}
+			best = score
+
+		case score == best:
+			good = append(good, square)
+
+		default:
+			bad = append(bad, square)
+		}
+	}
+
+	return best, good, bad, true
+}
+
+// negamax is how the board turns out for mark, to move, now that last
+// was played ply moves into the search, looking left moves further.
+// Scores outside alpha to beta only need to be known to be out there.
+func (e *Engine) negamax(b *Board, mark string, last, ply, left, alpha, beta int) int {
+
+	e.nodes++
+
+	if e.nodes%checkEvery == 0 && time.Now().After(e.deadline) {
+		e.aborted = true
+	}
+
+	if e.aborted {
+		return 0
+	}
+
+	// ONLY THE SIDE THAT JUST MOVED CAN HAVE WON
+	if b.wins(last) {
+		return ply - winScore
+	}
+
+	if b.Full() {
+		return 0
+	}
+
+	if left == 0 {
+		return evaluate(b, mark)
+	}
+
+	for _, square := range candidates(b, mark) {
+
+		b.Cells[square] = mark
+		score := -e.negamax(b, Other(mark), square, ply+1, left-1, -beta, -alpha)
+		b.Cells[square] = Empty
+
+		alpha = max(alpha, score)
+
+		if alpha >= beta {
+			break
+		}
+	}
+
+	return alpha
+}
+
+// candidates lists the moves worth trying for mark, the likeliest first:
+// every empty square near a mark already down.
+func candidates(b *Board, mark string) []int {
+
+	near := make([]bool, len(b.Cells))
+	marked := false
+
+	for square, m := range b.Cells {
+
+		if m == Empty {
+			continue
+		}
+
+		marked = true
+		row, col := square/b.N, square%b.N
+
+		for r := row - neighbourhood; r <= row+neighbourhood; r++ {
+			for c := col - neighbourhood; c <= col+neighbourhood; c++ {
+				if b.inside(r, c) {
+					near[b.Square(r, c)] = true
+				}
+			}
+		}
+	}
+
+	// AN EMPTY BIG BOARD IS STARTED IN THE MIDDLE, A SMALL ONE ANYWHERE
+	if !marked {
+
+		if b.N > 2*neighbourhood+1 {
+			return []int{b.Square(b.N/2, b.N/2)}
+		}
+
+		for square := range near {
+			near[square] = true
+		}
+	}
+
+	moves := []int{}
+	priority := make([]int, len(b.Cells))
+
+	for square, m := range b.Cells {
+		if m == Empty && near[square] {
+			moves = append(moves, square)
+			priority[square] = urgency(b, square, mark)
+		}
+	}
+
+	slices.SortStableFunc(moves, func(x, y int) int { return priority[y] - priority[x] })
+
+	return moves
+}
+
+// urgency is a quick guess at how much square matters to mark: most if it
+// wins, then if it stops the other side winning, then by the rows it
+// lengthens for either side.
+func urgency(b *Board, square int, mark string) int {
+
+	row, col := square/b.N, square%b.N
+	urgency := 0
+
+	for _, d := range directions {
+		for i, m := range []string{mark, Other(mark)} {
+
+			run := b.run(row, col, d[0], d[1], m) + b.run(row, col, -d[0], -d[1], m)
+
+			switch {
+			case run >= b.K-1 && i == 0:
+				urgency += 1 << 24
+			case run >= b.K-1:
+				urgency += 1 << 20
+			default:
+				urgency += 1 << (2 * min(run, 8))
+			}
+		}
+	}
+
+	return urgency
+}
+
+// evaluate scores a position the search stops at for mark, to move. Every
+// K squares in a row that only one side has marks in could still be its
+// line, worth more the more of it is filled.
+func evaluate(b *Board, mark string) int {
+
+	score := 0
+
+	for row := range b.N {
+		for col := range b.N {
+			for _, d := range directions {
+
+				end_row, end_col := row+(b.K-1)*d[0], col+(b.K-1)*d[1]
+
+				if !b.inside(end_row, end_col) {
+					continue
+				}
+
+				mine, theirs := 0, 0
+
+				for i := range b.K {
+					switch b.Cells[b.Square(row+i*d[0], col+i*d[1])] {
+					case Empty:
+					case mark:
+						mine++
+					default:
+						theirs++
+					}
+				}
+
+				// CAPPED, SO EVEN A BIG BOARD NEVER ADDS UP TO A WIN
+				switch {
+				case theirs == 0 && mine > 0:
+					score += 1 << (2 * min(mine, 7))
+				case mine == 0 && theirs > 0:
+					score -= 1 << (2 * min(theirs, 7))
+				}
+			}
+		}
+	}
+
+	return score
+}
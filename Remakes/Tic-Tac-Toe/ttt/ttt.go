@@ -0,0 +1,193 @@
+// Package ttt is Tic-Tac-Toe without the window: boards of any size from
+// 3×3 up, won by K marks in a row, and a computer player to fill one in.
+// It knows nothing about ebiten, so whole games can be played headless to
+// see how the computer does.
+package ttt
+
+import "fmt"
+
+// MARKS
+const (
+	X     = "X"
+	O     = "O"
+	Empty = ""
+)
+
+// BOARD LIMITS
+const (
+	MinSize = 3
+	MaxSize = 19
+)
+
+// the four ways a line can run, the other four being these backwards
+var directions = [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
+
+// BOARD
+// A Board is N squares by N, won by the first to get K of their marks in
+// a row across, down or diagonally. Squares are numbered from 0 row by
+// row, so square s is row s/N, column s%N.
+type Board struct {
+	N, K  int
+	Cells []string
+}
+
+// NewBoard is an empty board n by n, won with k in a row.
+func NewBoard(n, k int) (*Board, error) {
+
+	switch {
+	case n < MinSize || n > MaxSize:
+		return nil, fmt.Errorf("ttt: a board is %v to %v squares across, not %v", MinSize, MaxSize, n)
+	case k < MinSize || k > n:
+		return nil, fmt.Errorf("ttt: %v in a row can't be won on a board %v across", k, n)
+	}
+
+	return &Board{N: n, K: k, Cells: make([]string, n*n)}, nil
+}
+
+// Classic is the empty 3×3 board.
+func Classic() *Board {
+
+	b, _ := NewBoard(3, 3)
+	return b
+}
+
+// Other is the mark that isn't mark.
+func Other(mark string) string {
+
+	if mark == X {
+		return O
+	}
+
+	return X
+}
+
+// Clone is a copy of the board to play on without changing this one.
+func (b *Board) Clone() *Board {
+
+	c := *b
+	c.Cells = append([]string(nil), b.Cells...)
+
+	return &c
+}
+
+// Square is the number of the square at row, col.
+func (b *Board) Square(row, col int) int {
+
+	return row*b.N + col
+}
+
+func (b *Board) inside(row, col int) bool {
+
+	return row >= 0 && row < b.N && col >= 0 && col < b.N
+}
+
+// Free lists the empty squares, in order.
+func (b *Board) Free() []int {
+
+	free := []int{}
+
+	for square, mark := range b.Cells {
+		if mark == Empty {
+			free = append(free, square)
+		}
+	}
+
+	return free
+}
+
+// Full reports whether every square is taken.
+func (b *Board) Full() bool {
+
+	for _, mark := range b.Cells {
+		if mark == Empty {
+			return false
+		}
+	}
+
+	return true
+}
+
+// run is how many of mark lie in a row from row, col going dr, dc, not
+// counting row, col itself.
+func (b *Board) run(row, col, dr, dc int, mark string) int {
+
+	count := 0
+
+	for r, c := row+dr, col+dc; b.inside(r, c) && b.Cells[b.Square(r, c)] == mark; r, c = r+dr, c+dc {
+		count++
+	}
+
+	return count
+}
+
+// wins reports whether the mark on square makes K in a row through it.
+// After a move, it's all that needs checking.
+func (b *Board) wins(square int) bool {
+
+	mark := b.Cells[square]
+
+	if mark == Empty {
+		return false
+	}
+
+	row, col := square/b.N, square%b.N
+
+	for _, d := range directions {
+		if 1+b.run(row, col, d[0], d[1], mark)+b.run(row, col, -d[0], -d[1], mark) >= b.K {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Line is K squares in a row holding the same mark, or nil if there are
+// none.
+func (b *Board) Line() []int {
+
+	for square, mark := range b.Cells {
+
+		if mark == Empty {
+			continue
+		}
+
+		row, col := square/b.N, square%b.N
+
+		for _, d := range directions {
+
+			// ONLY FROM THE START OF A RUN, SO IT'S FOUND ONCE
+			if b.inside(row-d[0], col-d[1]) && b.Cells[b.Square(row-d[0], col-d[1])] == mark {
+				continue
+			}
+
+			if 1+b.run(row, col, d[0], d[1], mark) < b.K {
+				continue
+			}
+
+			line := make([]int, b.K)
+			for i := range line {
+				line[i] = b.Square(row+i*d[0], col+i*d[1])
+			}
+
+			return line
+		}
+	}
+
+	return nil
+}
+
+// Winner is the mark with K in a row, or Empty if neither has.
+func (b *Board) Winner() string {
+
+	if line := b.Line(); line != nil {
+		return b.Cells[line[0]]
+	}
+
+	return Empty
+}
+
+// Over reports whether someone has won or the board is full.
+func (b *Board) Over() bool {
+
+	return b.Winner() != Empty || b.Full()
+}